@@ -28,6 +28,8 @@ import (
 const (
 	shutdownTimeout   = 30 * time.Second
 	readHeaderTimeout = 5 * time.Second
+	docsFilePath      = "api_docs.json"
+	apiTitle          = "Local API"
 )
 
 //nolint:funlen
@@ -50,18 +52,39 @@ func main() {
 		fatalIfErr(logger, fmt.Errorf("failed to create generator: %w", err))
 	}
 
-	hub := rpc.NewHub(logger, g)
+	hub := rpc.NewHub(logger, g, rpc.HubOptions{
+		DevMode:           config.DevMode,
+		MaxClients:        config.MaxClients,
+		PingInterval:      config.PingInterval,
+		HeartbeatInterval: config.HeartbeatInterval,
+		NamePolicy:        &rpc.NamePolicyDotCase,
+		SessionStore:      rpc.NewMemorySessionStore(),
+	})
 	mux := http.NewServeMux()
 
-	methods := rpcapi.NewHandlers(hub)
+	methods := rpcapi.NewHandlers(hub, apiTitle)
 	hub.WithMiddleware(middleware.LoggingMiddleware)
 
+	// Payloads are only logged at debug level, redacting the @sensitive fields of the generated docs
+	if logger.Enabled(context.Background(), slog.LevelDebug) && !config.Generate {
+		docs, err := generate.LoadDocs(docsFilePath)
+		if err != nil {
+			logger.Warn("payload logging disabled, failed to load API docs", utils.ErrAttr(err))
+		} else {
+			hub.WithMiddleware(middleware.PayloadLogMiddleware(docs.SensitiveFields()))
+		}
+	}
+
 	// Register events
 	registerEvents(hub)
 
 	// Register methods
 	registerMethods(hub, methods)
 
+	if err := hub.NameErrors(); err != nil {
+		fatalIfErr(logger, fmt.Errorf("invalid event or method names: %w", err))
+	}
+
 	if err := hub.GenerateDocs(); err != nil {
 		fatalIfErr(logger, fmt.Errorf("failed to generate API docs: %w", err))
 	}
@@ -90,6 +113,9 @@ func main() {
 	logger.Info("Registering HTTP-RPC at /rpc")
 	mux.HandleFunc("/rpc", hub.ServeHTTP())
 
+	logger.Info("Registering SSE events at /events")
+	mux.HandleFunc("/events", hub.ServeSSE())
+
 	web.DocsApp().Register(mux, logger)
 	// Redirect root to docs
 	mux.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
@@ -126,6 +152,11 @@ func main() {
 	shutdownCtx, shutdownCancel := context.WithTimeout(context.Background(), shutdownTimeout)
 	defer shutdownCancel()
 
+	// The HTTP server does not close WebSocket connections, close them first
+	if err := hub.Shutdown(shutdownCtx); err != nil {
+		logger.Error("ws clients shutdown failed", utils.ErrAttr(err))
+	}
+
 	if err := httpServer.Shutdown(shutdownCtx); err != nil {
 		logger.Error("http/ws server shutdown failed", utils.ErrAttr(err))
 	}
@@ -148,6 +179,26 @@ func registerEvents(h *rpc.Hub) {
 				},
 			},
 		},
+		Snapshot: true,
+	})
+
+	rpc.RegisterEvent[rpctypes.SubscribedEvent](h, string(rpctypes.DirectEventKindSubscribed), rpc.EventOptions{
+		Docs: generate.EventDocs{
+			Title:       "Subscribed",
+			Description: "Event sent to a single client once its subscription is active, when it subscribes with confirm set",
+			Group:       "Core",
+			Examples: []generate.Example{
+				{
+					Title:       "Confirmation with snapshot",
+					Description: "Confirmation of a DataCreated subscription, carrying the latest published data",
+					ResultObj: rpctypes.SubscribedEvent{
+						Event:    rpctypes.EventKindDataCreated,
+						Snapshot: rpctypes.DataCreatedEvent{ID: uuid.MustParse("123e4567-e89b-12d3-a456-426614174000")},
+					},
+				},
+			},
+		},
+		Direct: true,
 	})
 }
 
@@ -170,6 +221,28 @@ func registerMethods(h *rpc.Hub, methods *rpcapi.Handlers) {
 		},
 	})
 
+	rpc.RegisterMethod(h, string(rpctypes.MethodKindRPCInfo), methods.RPCInfo, rpc.RegisterMethodOptions{
+		Docs: generate.MethodDocs{
+			Title:       "RPC Info",
+			Description: "Get the API title, server version and a hash of the API signature, to check the server is compatible",
+			Group:       "Core",
+			Tags:        []string{"version", "compatibility"},
+			Examples: []generate.Example{
+				{
+					Title:       "RPC Info",
+					Description: "Get the server info",
+					ParamsObj:   nil,
+					ResultObj: rpctypes.RPCInfoResult{
+						Title:         apiTitle,
+						Version:       "v1.2.0 (abc1234)",
+						Commit:        "abc1234",
+						SignatureHash: "9f86d081884c7d659a2feaa0c55ad015a3bf4f1b2b0b822cd15d6c15b0f00a08",
+					},
+				},
+			},
+		},
+	})
+
 	rpc.RegisterMethod(h, string(rpctypes.MethodKindSubscribe), methods.Subscribe, rpc.RegisterMethodOptions{
 		Docs: generate.MethodDocs{
 			Title:       "Subscribe",
@@ -181,7 +254,10 @@ func registerMethods(h *rpc.Hub, methods *rpcapi.Handlers) {
 					Title:       "Subscribe",
 					Description: "Subscribe to the DataCreated event",
 					ParamsObj:   rpctypes.SubscribeParams{Event: rpctypes.EventKindDataCreated},
-					ResultObj:   rpctypes.SubscribeResult{Success: true},
+					ResultObj: rpctypes.SubscribeResult{
+						Success:       true,
+						Subscriptions: []rpctypes.EventKind{rpctypes.EventKindDataCreated},
+					},
 				},
 			},
 			Errors: []generate.ErrorDoc{
@@ -206,7 +282,10 @@ func registerMethods(h *rpc.Hub, methods *rpcapi.Handlers) {
 					Title:       "Unsubscribe",
 					Description: "Unsubscribe from the DataCreated event",
 					ParamsObj:   rpctypes.UnsubscribeParams{Event: rpctypes.EventKindDataCreated},
-					ResultObj:   rpctypes.UnsubscribeResult{Success: true},
+					ResultObj: rpctypes.UnsubscribeResult{
+						Success:       true,
+						Subscriptions: []rpctypes.EventKind{},
+					},
 				},
 			},
 			Errors: []generate.ErrorDoc{
@@ -228,12 +307,22 @@ func generator(config *app.Config, logger *slog.Logger) (generate.Generator, err
 	}
 
 	return generate.NewGenerator(logger, generate.GeneratorOptions{
-		GoTypesDirPath:               "backend/internal/rpcapi/types",
-		DocsFileOutputPath:           "api_docs.json",
+		GoTypesDirPaths:              []string{"backend/internal/rpcapi/types"},
+		ReferenceDirPaths:            []string{"backend/pkg/rpc"},
+		StrictJSONTags:               true,
+		RequireDocs:                  true,
+		AutoExamples:                 true,
+		DocsFileOutputPath:           docsFilePath,
 		DatabaseSchemaFileOutputPath: "schema.sql",
+		SearchIndexFileOutputPath:    "api_search_index.json",
+		SpecFileOutputPath:           "api_spec.json",
+		SpecBaselinePath:             config.SpecBaseline,
+		WarningsFileOutputPath:       config.WarningsFile,
 		TSTypesOutputPath:            "web/ws-client/generated.ts",
+		Check:                        config.Check,
+		ValidateTypescript:           config.ValidateTS,
 		DocsOptions: generate.DocsOptions{
-			Title:       "Local API",
+			Title:       apiTitle,
 			Description: "A JSON-RPC API over HTTP and Websockets",
 		},
 	})
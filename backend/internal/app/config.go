@@ -8,25 +8,42 @@ import (
 	"path/filepath"
 	"strconv"
 	"strings"
+	"time"
 )
 
 type EnvKey string
 
 const (
-	EnvPort      EnvKey = "PORT"
-	EnvGenerate  EnvKey = "GENERATE"
-	EnvDataDir   EnvKey = "DATA_DIR"
-	EnvLogLevel  EnvKey = "LOG_LEVEL"
-	EnvLogToFile EnvKey = "LOG_TO_FILE"
+	EnvPort          EnvKey = "PORT"
+	EnvGenerate      EnvKey = "GENERATE"
+	EnvGenerateCheck EnvKey = "GENERATE_CHECK"
+	EnvValidateTS    EnvKey = "GENERATE_VALIDATE_TS"
+	EnvSpecBaseline  EnvKey = "GENERATE_SPEC_BASELINE"
+	EnvWarningsFile  EnvKey = "GENERATE_WARNINGS_FILE"
+	EnvDataDir       EnvKey = "DATA_DIR"
+	EnvLogLevel      EnvKey = "LOG_LEVEL"
+	EnvLogToFile     EnvKey = "LOG_TO_FILE"
+	EnvDevMode       EnvKey = "DEV_MODE"
+	EnvMaxClients    EnvKey = "MAX_CLIENTS"
+	EnvPingInterval  EnvKey = "PING_INTERVAL"
+	EnvHeartbeat     EnvKey = "HEARTBEAT_INTERVAL"
 )
 
 type Config struct {
-	Port      int
-	Generate  bool
-	DataDir   string
-	Database  string
-	LogLevel  slog.Leveler
-	LogOutput io.Writer
+	Port              int
+	Generate          bool
+	Check             bool   // Check generated files are up to date instead of writing them (implies Generate)
+	ValidateTS        bool   // Type-check the generated TypeScript with tsc
+	SpecBaseline      string // Path of a previous API spec, generation fails on breaking changes against it (implies Generate)
+	WarningsFile      string // Path to write the generation warnings to as JSON, for CI (optional)
+	DataDir           string
+	Database          string
+	LogLevel          slog.Leveler
+	LogOutput         io.Writer
+	DevMode           bool          // Expose internal error details to RPC clients, never enable in production
+	MaxClients        int           // Maximum number of connected WebSocket clients, zero means no limit
+	PingInterval      time.Duration // Interval of WebSocket ping frames, zero disables them
+	HeartbeatInterval time.Duration // Interval of "$ping" JSON-RPC heartbeats, zero disables them
 }
 
 func NewConfig() (*Config, error) {
@@ -53,13 +70,28 @@ func NewConfig() (*Config, error) {
 		logOutput = f
 	}
 
+	check := getBoolEnv(EnvGenerateCheck, false)
+	specBaseline := getStringEnv(EnvSpecBaseline, "")
+
+	// Intervals are given in seconds
+	pingInterval := time.Duration(getIntEnv(EnvPingInterval, 0)) * time.Second
+	heartbeatInterval := time.Duration(getIntEnv(EnvHeartbeat, 0)) * time.Second
+
 	return &Config{
-		Port:      getIntEnv(EnvPort, 8080),
-		Generate:  getBoolEnv(EnvGenerate, false),
-		DataDir:   dataDir,
-		Database:  dbPath,
-		LogLevel:  getLogLevelEnv(EnvLogLevel, slog.LevelInfo),
-		LogOutput: logOutput,
+		Port:              getIntEnv(EnvPort, 8080),
+		Generate:          getBoolEnv(EnvGenerate, false) || check || specBaseline != "",
+		Check:             check,
+		ValidateTS:        getBoolEnv(EnvValidateTS, false),
+		SpecBaseline:      specBaseline,
+		WarningsFile:      getStringEnv(EnvWarningsFile, ""),
+		DataDir:           dataDir,
+		Database:          dbPath,
+		LogLevel:          getLogLevelEnv(EnvLogLevel, slog.LevelInfo),
+		LogOutput:         logOutput,
+		DevMode:           getBoolEnv(EnvDevMode, false),
+		MaxClients:        getIntEnv(EnvMaxClients, 0),
+		PingInterval:      pingInterval,
+		HeartbeatInterval: heartbeatInterval,
 	}, nil
 }
 
@@ -3,9 +3,10 @@ package rpcapi
 import "ws-json-rpc/backend/pkg/rpc"
 
 type Handlers struct {
-	hub *rpc.Hub
+	hub   *rpc.Hub
+	title string // API title reported by rpc.info
 }
 
-func NewHandlers(hub *rpc.Hub) *Handlers {
-	return &Handlers{hub: hub}
+func NewHandlers(hub *rpc.Hub, title string) *Handlers {
+	return &Handlers{hub: hub, title: title}
 }
@@ -0,0 +1,17 @@
+package rpcapi
+
+import (
+	"context"
+	rpctypes "ws-json-rpc/backend/internal/rpcapi/types"
+	"ws-json-rpc/backend/pkg/rpc"
+	"ws-json-rpc/backend/pkg/utils"
+)
+
+func (h *Handlers) RPCInfo(ctx context.Context, hctx *rpc.HandlerContext, params struct{}) (rpctypes.RPCInfoResult, error) {
+	return rpctypes.RPCInfoResult{
+		Title:         h.title,
+		Version:       utils.GetVersionShort(),
+		Commit:        utils.GetBuildInfo()["commit"],
+		SignatureHash: h.hub.SignatureHash(),
+	}, nil
+}
@@ -2,6 +2,7 @@ package rpcapi
 
 import (
 	"context"
+	"encoding/json"
 	rpctypes "ws-json-rpc/backend/internal/rpcapi/types"
 	"ws-json-rpc/backend/pkg/rpc"
 )
@@ -11,11 +12,27 @@ func (h *Handlers) Subscribe(ctx context.Context, hctx *rpc.HandlerContext, para
 		return rpctypes.SubscribeResult{}, rpc.NewHandlerError(rpc.ErrCodeInvalid, "Subscriptions are only available for WebSocket connections")
 	}
 
-	if err := h.hub.Subscribe(hctx.WSConn, string(params.Event)); err != nil {
+	if !params.Confirm {
+		if err := h.hub.Subscribe(hctx.WSConn, string(params.Event)); err != nil {
+			return rpctypes.SubscribeResult{}, err
+		}
+
+		return rpctypes.SubscribeResult{Success: true, Subscriptions: h.subscriptions(hctx.WSConn)}, nil
+	}
+
+	err := h.hub.SubscribeAndConfirm(hctx.WSConn, string(params.Event), func(snapshot json.RawMessage) rpc.RPCEvent {
+		confirmation := rpctypes.SubscribedEvent{Event: params.Event}
+		if snapshot != nil {
+			confirmation.Snapshot = snapshot
+		}
+
+		return rpc.NewEvent(string(rpctypes.DirectEventKindSubscribed), confirmation)
+	})
+	if err != nil {
 		return rpctypes.SubscribeResult{}, err
 	}
 
-	return rpctypes.SubscribeResult{Success: true}, nil
+	return rpctypes.SubscribeResult{Success: true, Subscriptions: h.subscriptions(hctx.WSConn)}, nil
 }
 
 func (h *Handlers) Unsubscribe(ctx context.Context, hctx *rpc.HandlerContext, params rpctypes.UnsubscribeParams) (rpctypes.UnsubscribeResult, error) {
@@ -25,5 +42,17 @@ func (h *Handlers) Unsubscribe(ctx context.Context, hctx *rpc.HandlerContext, pa
 
 	h.hub.Unsubscribe(hctx.WSConn, string(params.Event))
 
-	return rpctypes.UnsubscribeResult{Success: true}, nil
+	return rpctypes.UnsubscribeResult{Success: true, Subscriptions: h.subscriptions(hctx.WSConn)}, nil
+}
+
+// subscriptions returns the event topics a client is subscribed to.
+func (h *Handlers) subscriptions(client *rpc.WSClient) []rpctypes.EventKind {
+	events := h.hub.Subscriptions(client)
+
+	kinds := make([]rpctypes.EventKind, len(events))
+	for i, event := range events {
+		kinds[i] = rpctypes.EventKind(event)
+	}
+
+	return kinds
 }
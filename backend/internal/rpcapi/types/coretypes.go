@@ -8,6 +8,13 @@ const (
 	EventKindDataUpdated EventKind = "data.updated"
 )
 
+// DirectEventKind - All the events sent to a single client, which can not be subscribed to.
+type DirectEventKind string
+
+const (
+	DirectEventKindSubscribed DirectEventKind = "subscribed"
+)
+
 // MethodKind - All the available RPC methods.
 type MethodKind string
 
@@ -15,6 +22,7 @@ const (
 	MethodKindPing        MethodKind = "ping"
 	MethodKindSubscribe   MethodKind = "subscribe"
 	MethodKindUnsubscribe MethodKind = "unsubscribe"
+	MethodKindRPCInfo     MethodKind = "rpc.info"
 	MethodKindUserCreate  MethodKind = "user.create"
 	MethodKindUserUpdate  MethodKind = "user.update"
 	MethodKindUserDelete  MethodKind = "user.delete"
@@ -7,6 +7,7 @@ import (
 // PingResult - Result for the [MethodKindPing] method.
 type PingResult struct {
 	// A message describing the result
+	// @example pong
 	Message string `json:"message"`
 	// The status of the ping
 	Status PingStatus `json:"status"`
@@ -30,6 +31,19 @@ func (e PingStatus) Valid() bool {
 	}
 }
 
+// RPCInfoResult - Result for the [MethodKindRPCInfo] method.
+type RPCInfoResult struct {
+	// The title of the API
+	Title string `json:"title"`
+	// The version of the server, including the build commit
+	Version string `json:"version"`
+	// The git commit the server was built from
+	// @example abc1234
+	Commit string `json:"commit"`
+	// A hash of the names and shapes of all methods and events, which changes whenever the API does
+	SignatureHash string `json:"signatureHash"`
+}
+
 // DataCreatedEvent - Result for the [EventKindDataCreated] event.
 type DataCreatedEvent struct {
 	// The unique identifier for the result
@@ -40,12 +54,24 @@ type DataCreatedEvent struct {
 type SubscribeParams struct {
 	// The event topic to subscribe to
 	Event EventKind `json:"event"`
+	// Send a [DirectEventKindSubscribed] event once the subscription is active
+	Confirm bool `json:"confirm,omitempty"`
+}
+
+// SubscribedEvent - Result for the [DirectEventKindSubscribed] event.
+type SubscribedEvent struct {
+	// The event topic the subscription is active for
+	Event EventKind `json:"event"`
+	// The latest data published for the event, if the event keeps a snapshot and was published before
+	Snapshot any `json:"snapshot,omitempty"`
 }
 
 // SubscribeResult - Result for the [MethodKindSubscribe] method.
 type SubscribeResult struct {
 	// Whether the subscribe was successful
 	Success bool `json:"success"`
+	// The event topics the client is subscribed to after the subscribe
+	Subscriptions []EventKind `json:"subscriptions"`
 }
 
 // UnsubscribeParams - Parameters for the [MethodKindUnsubscribe] method.
@@ -58,4 +84,6 @@ type UnsubscribeParams struct {
 type UnsubscribeResult struct {
 	// Whether the unsubscribe was successful
 	Success bool `json:"success"`
+	// The event topics the client is subscribed to after the unsubscribe
+	Subscriptions []EventKind `json:"subscriptions"`
 }
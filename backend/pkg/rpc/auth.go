@@ -0,0 +1,59 @@
+package rpc
+
+import (
+	"fmt"
+	"net/http"
+)
+
+// AuthorizerFunc decides whether a caller may call a method. It receives the identity of the caller
+// (see [HubOptions.IdentityFunc]), the called method and its group (see [Hub.MethodGroups]).
+// Aliases are passed with the group of the method they point to.
+type AuthorizerFunc func(identity string, method string, group string) bool
+
+// WithAuthorizer sets the function authorizing every method call, on all transports.
+// Denied calls fail with [ErrCodeForbidden] without reaching the handler or its middlewares.
+// It is meant to be set once all methods are registered, so it can be built from [Hub.MethodGroups].
+// Nil allows all calls, which is the default.
+func (h *Hub) WithAuthorizer(fn AuthorizerFunc) {
+	if fn == nil {
+		h.authorizer.Store(nil)
+
+		return
+	}
+
+	h.authorizer.Store(&fn)
+}
+
+// MethodGroups returns the group of every registered method (from the docs), keyed by method name.
+// Aliases are included with the group of the method they point to.
+func (h *Hub) MethodGroups() map[string]string {
+	h.methodsMutex.RLock()
+	defer h.methodsMutex.RUnlock()
+
+	groups := make(map[string]string, len(h.methods))
+	for name, method := range h.methods {
+		groups[name] = method.group
+	}
+
+	return groups
+}
+
+// authorize checks a call against the authorizer, returning the error object of denied calls.
+func (h *Hub) authorize(hctx *HandlerContext, name string, method Method) *RPCErrorObj {
+	authorizer := h.authorizer.Load()
+	if authorizer == nil || (*authorizer)(hctx.identity, name, method.group) {
+		return nil
+	}
+
+	return &RPCErrorObj{Code: ErrCodeForbidden, Message: fmt.Sprintf("Not allowed to call method %q", name)}
+}
+
+// identity resolves the identity of the caller of a request, see [HubOptions.IdentityFunc].
+// Returns an empty identity when no IdentityFunc is set.
+func (h *Hub) identity(r *http.Request) (string, error) {
+	if h.opts.IdentityFunc == nil {
+		return "", nil
+	}
+
+	return h.opts.IdentityFunc(r)
+}
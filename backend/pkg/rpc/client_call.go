@@ -0,0 +1,123 @@
+package rpc
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"ws-json-rpc/backend/pkg/utils"
+
+	"github.com/google/uuid"
+)
+
+// ErrClientDisconnected is returned by [WSClient.Call] when the client disconnects before responding.
+var ErrClientDisconnected = errors.New("client disconnected")
+
+// Call calls a method on the client and waits for its response, like the server to client requests of LSP.
+// The request has a server generated id, and the client answers it with a regular JSON-RPC response.
+// Returns the result of the client, or its error object as an [*RPCErrorObj]. Fails with [ErrClientDisconnected]
+// if the client disconnects before responding, or with the context error once the context expires.
+func (c *WSClient) Call(ctx context.Context, method string, params any) (json.RawMessage, error) {
+	req := RPCRequest{Version: "2.0", ID: NewRequestID(uuid.New()), Method: method}
+
+	if params != nil {
+		rawParams, err := utils.ToJSON(params)
+		if err != nil {
+			return nil, fmt.Errorf("failed to marshal params: %w", err)
+		}
+
+		req.Params = rawParams
+	}
+
+	msg, err := utils.ToJSON(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal request: %w", err)
+	}
+
+	// Buffered, so the read pump never blocks on delivering the response
+	pending := make(chan RPCResponse, 1)
+	key := string(req.ID)
+
+	c.callsMutex.Lock()
+	if c.calls == nil {
+		c.callsMutex.Unlock()
+
+		return nil, ErrClientDisconnected
+	}
+
+	c.calls[key] = pending
+	c.callsMutex.Unlock()
+
+	defer func() {
+		c.callsMutex.Lock()
+		delete(c.calls, key)
+		c.callsMutex.Unlock()
+	}()
+
+	select {
+	case c.sendChannel <- msg:
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+
+	select {
+	case resp, ok := <-pending:
+		if !ok {
+			return nil, ErrClientDisconnected
+		}
+
+		if resp.Error != nil {
+			return nil, resp.Error
+		}
+
+		return resp.Result, nil
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+// handleCallResponse routes a response of the client to the pending [WSClient.Call] with the same id.
+// Returns false if the message is not a response, so it is handled as a request.
+func (c *WSClient) handleCallResponse(message []byte) bool {
+	var resp struct {
+		Method string          `json:"method"`
+		ID     RequestID       `json:"id"`
+		Result json.RawMessage `json:"result"`
+		Error  *RPCErrorObj    `json:"error"`
+	}
+
+	if err := json.Unmarshal(message, &resp); err != nil || resp.Method != "" || (resp.Result == nil && resp.Error == nil) {
+		return false
+	}
+
+	c.callsMutex.Lock()
+	defer c.callsMutex.Unlock()
+
+	pending, ok := c.calls[string(resp.ID)]
+	if !ok {
+		c.logger.Warn("response to an unknown or expired call", slog.String("id", resp.ID.String()))
+
+		return true
+	}
+
+	// Duplicate responses are dropped, only the first one is delivered
+	select {
+	case pending <- RPCResponse{Version: "2.0", ID: resp.ID, Result: resp.Result, Error: resp.Error}:
+	default:
+	}
+
+	return true
+}
+
+// failCalls fails all pending calls once the client disconnected, and any later ones.
+func (c *WSClient) failCalls() {
+	c.callsMutex.Lock()
+	defer c.callsMutex.Unlock()
+
+	for _, pending := range c.calls {
+		close(pending)
+	}
+
+	c.calls = nil
+}
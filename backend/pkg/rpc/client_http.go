@@ -4,8 +4,8 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"io"
 	"log/slog"
-	"net"
 	"net/http"
 	"ws-json-rpc/backend/pkg/utils"
 
@@ -18,75 +18,65 @@ type HTTPClient struct {
 	hub        *Hub
 	remoteHost string
 	id         string
+	identity   string
 	logger     *slog.Logger
 }
 
 func (c *HTTPClient) handleRequest(ctx context.Context, req RPCRequest) {
-	reqLogger := c.logger.With(slog.String("method", req.Method))
-	reqLogger = reqLogger.With(slog.String("id", req.ID.String()))
+	// Create a new HandlerContext
+	hctx := &HandlerContext{
+		Logger:     requestLogger(c.logger, req),
+		WSConn:     nil,
+		HTTPConn:   c,
+		remoteAddr: c.remoteHost,
+		tls:        c.r.TLS,
+		identity:   c.identity,
+	}
+
+	// Signal deprecation to clients in a machine-readable way
+	if method, exists := c.hub.getMethod(req.Method); exists && method.deprecated {
+		c.setDeprecationHeaders(method)
+	}
 
-	// Get the handler
-	c.hub.methodsMutex.RLock()
-	method, exists := c.hub.methods[req.Method]
-	c.hub.methodsMutex.RUnlock()
+	result, rpcErr := c.hub.dispatch(ctx, hctx, req)
 
-	if !exists {
-		c.sendError(req.ID, ErrCodeNotFound, fmt.Sprintf("Method %q not found", req.Method))
+	// Notifications get no response, not even an error
+	if req.ID.IsAbsent() {
+		c.w.WriteHeader(http.StatusNoContent)
 
 		return
 	}
 
-	// Parse json into the structured params
-	typedParams, err := method.parser(req.Params)
-	if err != nil {
-		reqLogger.Error("unmarshal error", utils.ErrAttr(err))
-		c.sendError(req.ID, ErrCodeInvalidParams, fmt.Sprintf("Failed to parse params on method %q: %s", req.Method, err.Error()))
+	if rpcErr != nil {
+		c.sendResponse(NewRPCResponse(req.ID, nil, rpcErr), http.StatusOK)
 
 		return
 	}
 
-	// Set a timeout for the request
-	ctx, cancel := context.WithTimeout(ctx, MAX_REQUEST_TIMEOUT)
-	defer cancel()
-
-	// Create a new HandlerContext
-	hctx := &HandlerContext{
-		Logger:   reqLogger,
-		WSConn:   nil,
-		HTTPConn: c,
+	status := hctx.status
+	if status == 0 {
+		status = http.StatusOK
 	}
 
-	// Call the handler
-	result, err := method.handler(ctx, hctx, typedParams)
-	if err != nil {
-		hctx.Logger.Error("handler error", utils.ErrAttr(err))
-		// If its a handler error, let handler specify code/message
-		var he HandlerError
-		if errors.As(err, &he) {
-			c.sendError(req.ID, he.Code(), he.Error())
-
-			return
-		}
+	c.sendSuccess(req.ID, result, status)
+}
 
-		// Unknown errors, send internal error
-		c.sendError(req.ID, ErrCodeInternal, fmt.Sprintf("Failed to handle request on method %q: %s", req.Method, err.Error()))
+// setDeprecationHeaders sets the Deprecation and Sunset (if known) headers for a deprecated method.
+func (c *HTTPClient) setDeprecationHeaders(method Method) {
+	c.w.Header().Set("Deprecation", "true")
 
-		return
+	if !method.sunset.IsZero() {
+		c.w.Header().Set("Sunset", method.sunset.UTC().Format(http.TimeFormat))
 	}
-
-	c.sendSuccess(req.ID, result)
 }
 
-func (c *HTTPClient) sendSuccess(id uuid.UUID, result any) {
-	c.sendResponse(NewRPCResponse(id, result, nil))
+func (c *HTTPClient) sendSuccess(id RequestID, result any, status int) {
+	c.sendResponse(NewRPCResponse(id, result, nil), status)
 }
 
-func (c *HTTPClient) sendError(id uuid.UUID, code int, message string) {
-	c.sendResponse(NewRPCResponse(id, nil, &RPCErrorObj{Code: code, Message: message}))
-}
-
-func (c *HTTPClient) sendResponse(resp RPCResponse) {
+func (c *HTTPClient) sendResponse(resp RPCResponse, status int) {
 	c.w.Header().Set("Content-Type", "application/json")
+	c.w.WriteHeader(status)
 
 	if err := utils.ToJSONStream(c.w, resp); err != nil {
 		c.logger.Error("failed to encode HTTP response", utils.ErrAttr(err))
@@ -106,14 +96,37 @@ func (h *Hub) ServeHTTP() http.HandlerFunc {
 			return
 		}
 
+		identity, err := h.identity(r)
+		if err != nil {
+			httpLogger.Warn("rejecting unauthenticated request", utils.ErrAttr(err))
+			http.Error(w, "Unauthorized", http.StatusUnauthorized)
+
+			return
+		}
+
 		// Limit the size of the request body
 		r.Body = http.MaxBytesReader(w, r.Body, MAX_MESSAGE_SIZE)
 
-		// Parse the request using streaming JSON helper
-		req, err := utils.FromJSONStream[RPCRequest](r.Body)
+		body, err := io.ReadAll(r.Body)
 		if err != nil {
+			httpLogger.Warn("failed to read HTTP request body", utils.ErrAttr(err))
+
+			var maxErr *http.MaxBytesError
+			if errors.As(err, &maxErr) {
+				http.Error(w, "Request body too large", http.StatusRequestEntityTooLarge)
+
+				return
+			}
+
+			http.Error(w, "Failed to read request body", http.StatusBadRequest)
+
+			return
+		}
+
+		req, rpcErr := parseRequest(body)
+		if rpcErr != nil {
 			// Create a minimal error response
-			resp := NewRPCResponse(uuid.Nil, nil, &RPCErrorObj{Code: ErrCodeParse, Message: "Invalid JSON in request body"})
+			resp := NewRPCResponse(req.ID, nil, rpcErr)
 
 			w.Header().Set("Content-Type", "application/json")
 
@@ -125,7 +138,7 @@ func (h *Hub) ServeHTTP() http.HandlerFunc {
 			return
 		}
 
-		remoteHost, _, err := net.SplitHostPort(r.RemoteAddr)
+		remoteHost, err := h.remoteHost(r)
 		if err != nil {
 			httpLogger.Error("failed to parse remote address", utils.ErrAttr(err), slog.String("remote_addr", r.RemoteAddr))
 
@@ -137,7 +150,7 @@ func (h *Hub) ServeHTTP() http.HandlerFunc {
 
 		clientID := r.Header.Get("X-Client-ID")
 		if clientID == "" {
-			httpLogger.Warn("no client ID provided, generating one", slog.String("remote_addr", remoteHost))
+			httpLogger.Warn("no client ID provided, generating one", slog.String("remote_host", remoteHost))
 			clientID = fmt.Sprintf("http-%s-%s", remoteHost, uuid.NewString())
 		}
 
@@ -147,6 +160,7 @@ func (h *Hub) ServeHTTP() http.HandlerFunc {
 			hub:        h,
 			remoteHost: remoteHost,
 			id:         clientID,
+			identity:   identity,
 			logger: httpLogger.With(
 				slog.String("client_id", clientID),
 				slog.String("remote_host", remoteHost),
@@ -0,0 +1,71 @@
+package rpc
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+type deleteParams struct {
+	IDs []string `json:"ids"`
+}
+
+type deleteResult PartialResult[string]
+
+func TestSetStatus(t *testing.T) {
+	h := newTestHub(t, HubOptions{})
+
+	RegisterMethod(h, "create", func(_ context.Context, hctx *HandlerContext, params echoParams) (echoResult, error) {
+		hctx.SetStatus(http.StatusCreated)
+
+		return echoResult(params), nil
+	}, RegisterMethodOptions{})
+	RegisterMethod(h, "invalid", func(_ context.Context, hctx *HandlerContext, params echoParams) (echoResult, error) {
+		hctx.SetStatus(http.StatusInternalServerError)
+
+		return echoResult(params), nil
+	}, RegisterMethodOptions{})
+	RegisterMethod(h, "delete", func(_ context.Context, hctx *HandlerContext, params deleteParams) (deleteResult, error) {
+		result := NewPartialResult[string]()
+
+		for i, id := range params.IDs {
+			if id == "" {
+				result.AddError(i, ErrCodeInvalidParams, "empty id")
+
+				continue
+			}
+
+			result.AddResult(id)
+		}
+
+		hctx.SetStatus(result.Status())
+
+		return deleteResult(result), nil
+	}, RegisterMethodOptions{})
+
+	tests := []struct {
+		name   string
+		body   string
+		status int
+	}{
+		{name: "default", body: `{"jsonrpc":"2.0","id":1,"method":"echo","params":{"message":"hi"}}`, status: http.StatusOK},
+		{name: "created", body: `{"jsonrpc":"2.0","id":1,"method":"create","params":{"message":"hi"}}`, status: http.StatusCreated},
+		{name: "invalid status ignored", body: `{"jsonrpc":"2.0","id":1,"method":"invalid","params":{"message":"hi"}}`, status: http.StatusOK},
+		{name: "error", body: `{"jsonrpc":"2.0","id":1,"method":"fail"}`, status: http.StatusOK},
+		{name: "partial success", body: `{"jsonrpc":"2.0","id":1,"method":"delete","params":{"ids":["a",""]}}`, status: http.StatusMultiStatus},
+		{name: "full success", body: `{"jsonrpc":"2.0","id":1,"method":"delete","params":{"ids":["a","b"]}}`, status: http.StatusOK},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec := httptest.NewRecorder()
+			h.ServeHTTP()(rec, httptest.NewRequest(http.MethodPost, "/rpc", strings.NewReader(tt.body)))
+
+			if rec.Code != tt.status {
+				t.Errorf("status = %d, want %d (body %s)", rec.Code, tt.status, rec.Body)
+			}
+		})
+	}
+}
@@ -0,0 +1,117 @@
+package rpc
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"ws-json-rpc/backend/pkg/utils"
+
+	"github.com/google/uuid"
+)
+
+// TestClient is an in-memory client, useful for unit testing methods and events without a network.
+// It connects and calls go through the same paths as the real transports (registration, JSON params parsing,
+// middlewares and error mapping). For handlers it looks like a WebSocket client,
+// so it can subscribe to events. The hub must be running ([Hub.Run]).
+type TestClient struct {
+	hub    *Hub
+	client *WSClient
+}
+
+// TestEvent is an event received by a TestClient.
+type TestEvent struct {
+	EventName string          `json:"event"`
+	Data      json.RawMessage `json:"data"`
+}
+
+// NewTestClient creates and registers a new in-memory client on the hub.
+// Returns an error if the hub rejects it, like a real client, when it reached [HubOptions.MaxClients].
+// Call [TestClient.Close] to unregister it.
+func NewTestClient(h *Hub) (*TestClient, error) {
+	clientID := "test-" + uuid.NewString()
+
+	client := &WSClient{
+		hub:         h,
+		id:          clientID,
+		remoteHost:  "test",
+		cancel:      func() {},
+		sendChannel: make(chan []byte, MAX_QUEUED_EVENTS_PER_CLIENT),
+		registered:  make(chan struct{}),
+		logger:      h.logger.With(slog.String("handler", "test"), slog.String("remote_host", "test")),
+	}
+
+	h.register <- client
+	// Wait for the registration to finish, it may change the client ID
+	<-client.registered
+
+	if client.rejected {
+		return nil, errors.New("client rejected, the hub is full")
+	}
+
+	return &TestClient{hub: h, client: client}, nil
+}
+
+// WSClient returns the underlying client, as seen by handlers in [HandlerContext.WSConn].
+func (c *TestClient) WSClient() *WSClient {
+	return c.client
+}
+
+// Call calls a method with the given params and returns the raw JSON result.
+// Errors returned by the method are returned as *[RPCErrorObj].
+func (c *TestClient) Call(ctx context.Context, method string, params any) (json.RawMessage, error) {
+	rawParams, err := utils.ToJSON(params)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal params: %w", err)
+	}
+
+	req := RPCRequest{Version: "2.0", ID: NewRequestID(uuid.New()), Method: method, Params: rawParams}
+
+	hctx := &HandlerContext{
+		Logger:     requestLogger(c.client.logger, req),
+		WSConn:     c.client,
+		remoteAddr: c.client.remoteHost,
+	}
+
+	result, rpcErr := c.hub.dispatch(ctx, hctx, req)
+	if rpcErr != nil {
+		return nil, rpcErr
+	}
+
+	resp := NewRPCResponse(req.ID, result, nil)
+	if resp.Error != nil {
+		return nil, resp.Error
+	}
+
+	return resp.Result, nil
+}
+
+// NextEvent waits for the next event delivered to the client.
+func (c *TestClient) NextEvent(ctx context.Context) (TestEvent, error) {
+	select {
+	case msg := <-c.client.sendChannel:
+		return utils.FromJSON[TestEvent](msg)
+	case <-ctx.Done():
+		return TestEvent{}, ctx.Err()
+	}
+}
+
+// Close unregisters the client from the hub, removing all its subscriptions.
+func (c *TestClient) Close() {
+	c.hub.unregister <- c.client
+}
+
+// TypedCall calls a method using a TestClient and decodes the result into TResult.
+//
+//nolint:ireturn
+func TypedCall[TResult any](ctx context.Context, c *TestClient, method string, params any) (TResult, error) {
+	var zero TResult
+
+	raw, err := c.Call(ctx, method, params)
+	if err != nil {
+		return zero, err
+	}
+
+	return utils.FromJSON[TResult](raw)
+}
@@ -0,0 +1,108 @@
+package rpc
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func newTestClient(t *testing.T, h *Hub) *TestClient {
+	t.Helper()
+
+	c, err := NewTestClient(h)
+	if err != nil {
+		t.Fatalf("NewTestClient() error = %v", err)
+	}
+
+	t.Cleanup(c.Close)
+
+	return c
+}
+
+func TestTestClientCall(t *testing.T) {
+	c := newTestClient(t, newTestHub(t, HubOptions{}))
+
+	result, err := TypedCall[echoResult](t.Context(), c, "echo", echoParams{Message: "hello"})
+	if err != nil {
+		t.Fatalf("TypedCall() error = %v", err)
+	}
+
+	if result.Message != "hello" {
+		t.Errorf("TypedCall() message = %q, want %q", result.Message, "hello")
+	}
+}
+
+func TestTestClientCallError(t *testing.T) {
+	c := newTestClient(t, newTestHub(t, HubOptions{}))
+
+	tests := []struct {
+		method string
+		params any
+		code   int
+	}{
+		{method: "fail", params: struct{}{}, code: -32042},
+		{method: "missing", params: struct{}{}, code: ErrCodeNotFound},
+		{method: "echo", params: []int{1}, code: ErrCodeInvalidParams},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.method, func(t *testing.T) {
+			_, err := c.Call(t.Context(), tt.method, tt.params)
+
+			var rpcErr *RPCErrorObj
+			if !errors.As(err, &rpcErr) {
+				t.Fatalf("Call() error = %v, want *RPCErrorObj", err)
+			}
+
+			if rpcErr.Code != tt.code {
+				t.Errorf("Call() error code = %d, want %d", rpcErr.Code, tt.code)
+			}
+		})
+	}
+}
+
+func TestTestClientEvents(t *testing.T) {
+	h := newTestHub(t, HubOptions{})
+	c := newTestClient(t, h)
+
+	if err := h.Subscribe(c.WSClient(), "ping"); err != nil {
+		t.Fatalf("Subscribe() error = %v", err)
+	}
+
+	h.PublishEvent(NewEvent("ping", echoResult{Message: "hello"}))
+
+	ctx, cancel := context.WithTimeout(t.Context(), time.Second)
+	defer cancel()
+
+	event, err := c.NextEvent(ctx)
+	if err != nil {
+		t.Fatalf("NextEvent() error = %v", err)
+	}
+
+	if event.EventName != "ping" || string(event.Data) != `{"message":"hello"}` {
+		t.Errorf("NextEvent() = %s %s, want ping {\"message\":\"hello\"}", event.EventName, event.Data)
+	}
+}
+
+func TestTestClientRegistration(t *testing.T) {
+	h := newTestHub(t, HubOptions{})
+	c := newTestClient(t, h)
+
+	h.clientsMutex.RLock()
+	_, registered := h.clients[c.WSClient()]
+	h.clientsMutex.RUnlock()
+
+	if !registered {
+		t.Error("NewTestClient() client is not registered on the hub")
+	}
+}
+
+func TestTestClientRejectedWhenFull(t *testing.T) {
+	h := newTestHub(t, HubOptions{MaxClients: 1})
+	newTestClient(t, h)
+
+	if _, err := NewTestClient(h); err == nil {
+		t.Error("NewTestClient() on a full hub error = nil, want an error")
+	}
+}
@@ -0,0 +1,192 @@
+package rpc
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"time"
+	"ws-json-rpc/backend/pkg/utils"
+)
+
+// SSE_EVENT_QUERY_PARAM is the query parameter listing the events an SSE client subscribes to, see [Hub.ServeSSE].
+const SSE_EVENT_QUERY_PARAM = "event"
+
+// ServeSSE streams events as Server-Sent Events (text/event-stream), for clients that can not use WebSockets,
+// e.g. behind proxies that block them. Clients subscribe with one "event" query parameter per event, like
+// /events?event=data.created&event=data.updated. Every event is sent with its name as the SSE event type and
+// the event message (as sent over WebSockets) as data. The stream ends when the client disconnects.
+//
+// SSE clients are registered like WebSocket clients, reusing the subscriptions and the broadcast,
+// so they count towards [HubOptions.MaxClients]. They can only receive events, not call methods.
+// When [HubOptions.PingInterval] is set, a comment is sent at that interval to keep proxies from timing out.
+func (h *Hub) ServeSSE() http.HandlerFunc {
+	sseLogger := h.logger.With(slog.String("handler", "sse"))
+
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			sseLogger.Warn("sse request not allowed", slog.String("method", r.Method))
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+
+			return
+		}
+
+		events := r.URL.Query()[SSE_EVENT_QUERY_PARAM]
+		if len(events) == 0 {
+			http.Error(w, "At least one event query parameter is required", http.StatusBadRequest)
+
+			return
+		}
+
+		remoteHost, err := h.remoteHost(r)
+		if err != nil {
+			sseLogger.Error("failed to parse remote address", utils.ErrAttr(err), slog.String("remote_addr", r.RemoteAddr))
+			http.Error(w, "Bad request", http.StatusBadRequest)
+
+			return
+		}
+
+		clientID, err := h.clientID(r, remoteHost)
+		if err != nil {
+			sseLogger.Warn("failed to get client ID", utils.ErrAttr(err), slog.String("remote_host", remoteHost))
+			http.Error(w, "Invalid client ID", http.StatusBadRequest)
+
+			return
+		}
+
+		identity, err := h.identity(r)
+		if err != nil {
+			sseLogger.Warn("rejecting unauthenticated client", utils.ErrAttr(err), slog.String("remote_host", remoteHost))
+			http.Error(w, "Unauthorized", http.StatusUnauthorized)
+
+			return
+		}
+
+		ctx, cancel := context.WithCancel(r.Context())
+		defer cancel()
+
+		client := &WSClient{
+			hub:         h,
+			id:          clientID,
+			remoteHost:  remoteHost,
+			tls:         r.TLS,
+			identity:    identity,
+			cancel:      cancel,
+			sendChannel: make(chan []byte, MAX_QUEUED_EVENTS_PER_CLIENT),
+			registered:  make(chan struct{}),
+			// The client_id is attached on registration, once the final ID is known
+			logger: sseLogger.With(slog.String("remote_host", remoteHost)),
+		}
+
+		h.register <- client
+		// Wait for the registration to finish, it may change the client ID
+		<-client.registered
+
+		if client.rejected {
+			w.Header().Set("Retry-After", "5")
+			http.Error(w, "Server is full or shutting down, try again later", http.StatusServiceUnavailable)
+
+			return
+		}
+
+		defer func() {
+			h.unregister <- client
+		}()
+
+		for _, event := range events {
+			if err := h.Subscribe(client, event); err != nil {
+				http.Error(w, fmt.Sprintf("Invalid event: %s", err.Error()), http.StatusBadRequest)
+
+				return
+			}
+		}
+
+		w.Header().Set("Content-Type", "text/event-stream")
+		w.Header().Set("Cache-Control", "no-cache")
+		// Stop reverse proxies like nginx from buffering the stream
+		w.Header().Set("X-Accel-Buffering", "no")
+		w.WriteHeader(http.StatusOK)
+
+		client.streamEvents(ctx, http.NewResponseController(w), w)
+	}
+}
+
+// streamEvents writes the queued events of an SSE client until the client disconnects or a write fails.
+func (c *WSClient) streamEvents(ctx context.Context, rc *http.ResponseController, w http.ResponseWriter) {
+	// Flush the headers, so the client knows the subscription is active
+	if err := rc.Flush(); err != nil {
+		c.logger.Error("failed to flush sse stream", utils.ErrAttr(err))
+
+		return
+	}
+
+	var keepAlive <-chan time.Time
+
+	if c.hub.opts.PingInterval > 0 {
+		ticker := time.NewTicker(c.hub.opts.PingInterval)
+		defer ticker.Stop()
+
+		keepAlive = ticker.C
+	}
+
+	for {
+		var frame []byte
+
+		select {
+		case <-ctx.Done():
+			c.logger.Info("sse stream closed")
+
+			return
+		case <-keepAlive:
+			frame = []byte(": ping\n\n")
+		case message := <-c.sendChannel:
+			frame = sseFrame(message)
+		}
+
+		if err := c.writeSSE(rc, w, frame); err != nil {
+			c.logger.Info("closing sse stream, write failed", utils.ErrAttr(err))
+
+			return
+		}
+
+		c.touch()
+	}
+}
+
+// writeSSE writes and flushes a frame to an SSE stream, bounded by the hub's WriteTimeout.
+func (c *WSClient) writeSSE(rc *http.ResponseController, w http.ResponseWriter, frame []byte) error {
+	if err := rc.SetWriteDeadline(time.Now().Add(c.hub.opts.WriteTimeout)); err != nil {
+		return err
+	}
+
+	if _, err := w.Write(frame); err != nil {
+		return err
+	}
+
+	return rc.Flush()
+}
+
+// sseFrame formats an event message as an SSE frame, using the event name as the SSE event type.
+// Messages are compact JSON, so they never contain the newlines that would end the data field.
+func sseFrame(message []byte) []byte {
+	var event struct {
+		EventName string `json:"event"`
+	}
+
+	// The message is always an event, marshaled by the hub
+	_ = json.Unmarshal(message, &event)
+
+	var b bytes.Buffer
+
+	if event.EventName != "" {
+		b.WriteString("event: " + event.EventName + "\n")
+	}
+
+	b.WriteString("data: ")
+	b.Write(message)
+	b.WriteString("\n\n")
+
+	return b.Bytes()
+}
@@ -2,12 +2,17 @@ package rpc
 
 import (
 	"context"
+	"crypto/tls"
 	"errors"
 	"fmt"
 	"io"
 	"log/slog"
-	"net"
+	"maps"
 	"net/http"
+	"slices"
+	"strconv"
+	"sync"
+	"sync/atomic"
 	"time"
 	"ws-json-rpc/backend/pkg/utils"
 
@@ -17,13 +22,74 @@ import (
 
 // WSClient represents a connected WebSocket client.
 type WSClient struct {
-	conn        *websocket.Conn
-	sendChannel chan []byte
-	hub         *Hub
-	remoteHost  string
-	cancel      context.CancelFunc
-	id          string
-	logger      *slog.Logger
+	conn         *websocket.Conn
+	sendChannel  chan []byte
+	events       chan []byte // Events waiting to be batched (nil unless the client opted in to event batching)
+	hub          *Hub
+	remoteHost   string
+	tls          *tls.ConnectionState
+	identity     string                      // Identity of the caller, resolved from the upgrade request
+	registered   chan struct{}               // Closed once the hub has registered the client
+	rejected     bool                        // Whether the hub refused to register the client, set before registered is closed
+	ordered      chan chan RPCResponse       // Response slots in request order (nil unless OrderedResponses is enabled)
+	msgType      atomic.Int32                // Frame type negotiated from the first frame (0 until then)
+	lastActive   atomic.Int64                // Unix nano time of the last inbound message or delivered event
+	handshake    atomic.Int32                // State of the handshake (see [RegisterMethodOptions.Handshake])
+	caps         atomic.Value                // Result of the handshake method, holding the negotiated capabilities
+	pongs        chan struct{}               // Signalled on every "$pong" (nil unless HeartbeatInterval is set)
+	calls        map[string]chan RPCResponse // Pending [WSClient.Call]s by raw id, nil once the client disconnected
+	sessionToken string                      // Token of the resumable session, guarded by the hub clientsMutex, see [HubOptions.SessionStore]
+	callsMutex   sync.Mutex
+	cancel       context.CancelFunc
+	id           string
+	logger       *slog.Logger
+}
+
+// ID returns the unique ID of the client, as resolved on registration.
+func (c *WSClient) ID() string {
+	return c.id
+}
+
+// States of the handshake of a WebSocket client.
+const (
+	handshakePending int32 = iota // The handshake method was not called yet, or failed
+	handshakeRunning              // The handshake method is running
+	handshakeDone                 // The handshake method completed
+)
+
+// Initialized returns true once the client completed the handshake (see [RegisterMethodOptions.Handshake]).
+func (c *WSClient) Initialized() bool {
+	return c.handshake.Load() == handshakeDone
+}
+
+// Capabilities returns the result of the handshake method, holding the capabilities negotiated by the client.
+// It is nil until the handshake completed, handlers can type assert it to the handshake result type.
+func (c *WSClient) Capabilities() any {
+	caps, _ := c.caps.Load().(capabilities)
+
+	return caps.value
+}
+
+// capabilities wraps the handshake result, as an atomic.Value requires a consistent concrete type.
+type capabilities struct {
+	value any
+}
+
+// claimHandshake marks the handshake as running, before calling the handshake method.
+// Returns false if the handshake is already running or done, so only one handshake call can succeed.
+func (c *WSClient) claimHandshake() bool {
+	return c.handshake.CompareAndSwap(handshakePending, handshakeRunning)
+}
+
+// releaseHandshake gives up a claimed handshake that failed, so the client can call the handshake method again.
+func (c *WSClient) releaseHandshake() {
+	c.handshake.CompareAndSwap(handshakeRunning, handshakePending)
+}
+
+// initialize stores the handshake result and marks the client as initialized.
+func (c *WSClient) initialize(result any) {
+	c.caps.Store(capabilities{value: result})
+	c.handshake.Store(handshakeDone)
 }
 
 func (c *WSClient) readPump(ctx context.Context) {
@@ -31,6 +97,7 @@ func (c *WSClient) readPump(ctx context.Context) {
 	defer func() {
 		c.logger.Info("client read pump exited")
 		c.cancel()
+		c.failCalls()
 
 		c.hub.unregister <- c
 	}()
@@ -52,38 +119,102 @@ func (c *WSClient) readPump(ctx context.Context) {
 
 			break
 		}
-		// Only support text based messages
-		if msgType != websocket.MessageText {
-			if err := c.sendError(ctx, uuid.Nil, ErrCodeInvalid, "Invalid message type. Only text messages are supported."); err != nil {
+
+		c.touch()
+
+		// Reserve the response position of this message (nil if responses are not ordered)
+		slot := c.nextSlot(ctx)
+
+		// The first frame decides the frame type of the connection, mismatched frames are rejected
+		if !c.negotiateMessageType(msgType) {
+			msg := fmt.Sprintf("Invalid message type. This connection uses %s messages.", c.messageType())
+			if err := c.sendError(ctx, slot, NullID, ErrCodeInvalid, msg); err != nil {
 				c.logger.Error("failed to send error response", utils.ErrAttr(err))
 			}
 
 			continue
 		}
 
-		// Parse message
-		req, err := utils.FromJSON[RPCRequest](message)
-		if err != nil {
-			c.logger.Warn("parse error", utils.ErrAttr(err))
+		// Responses to server initiated calls are routed to the waiting caller
+		if c.handleCallResponse(message) {
+			c.skip(slot)
+
+			continue
+		}
+
+		// Parse message, malformed messages are answered with an error and the connection stays open
+		req, rpcErr := parseRequest(message)
+		if rpcErr != nil {
+			c.logger.Warn("invalid request", slog.String("id", req.ID.String()), slog.Int("code", rpcErr.Code), slog.String("error", rpcErr.Message))
 
-			if err := c.sendError(ctx, uuid.Nil, ErrCodeParse, err.Error()); err != nil {
+			if err := c.sendError(ctx, slot, req.ID, rpcErr.Code, rpcErr.Message); err != nil {
 				c.logger.Error("failed to send error response", utils.ErrAttr(err))
 			}
 
 			continue
 		}
 
+		// Heartbeat messages are answered by the hub and never reach the handlers
+		if c.handleHeartbeat(ctx, req, slot) {
+			continue
+		}
+
 		// Handle the request
-		go c.handleRequest(ctx, req)
+		go c.handleRequest(ctx, req, slot)
+	}
+}
+
+// nextSlot reserves the position of the next response when [HubOptions.OrderedResponses] is enabled.
+// Returns nil when responses are sent as soon as they are ready.
+// Blocks while the maximum number of responses is pending, applying backpressure to the reader.
+func (c *WSClient) nextSlot(ctx context.Context) chan RPCResponse {
+	if c.ordered == nil {
+		return nil
+	}
+
+	// Buffered, so handlers never block on filling their slot
+	slot := make(chan RPCResponse, 1)
+
+	select {
+	case c.ordered <- slot:
+	case <-ctx.Done():
+	}
+
+	return slot
+}
+
+// orderedPump sends the responses of ordered clients in request order.
+// It waits for each response in turn, so a slow request delays all the responses after it.
+func (c *WSClient) orderedPump(ctx context.Context) {
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case slot := <-c.ordered:
+			select {
+			case <-ctx.Done():
+				return
+			case resp, ok := <-slot:
+				// Closed slots belong to notifications, which get no response
+				if !ok {
+					continue
+				}
+
+				if err := c.sendData(ctx, resp); err != nil {
+					c.logger.Error("failed to send response", slog.String("id", resp.ID.String()), utils.ErrAttr(err))
+				}
+			}
+		}
 	}
 }
 
 func (c *WSClient) writePump(ctx context.Context) {
-	// When writePump exits, cancel the context and close the send channel
+	// When writePump exits, cancel the context, which also stops the readPump.
+	// The send channel is intentionally left open, as handlers and broadcasts may still
+	// be sending to it, they give up once the context is cancelled or the channel is full.
 	defer func() {
 		c.logger.Info("client write pump exited")
 		c.cancel()
-		close(c.sendChannel)
 	}()
 
 	for {
@@ -96,102 +227,333 @@ func (c *WSClient) writePump(ctx context.Context) {
 			}
 
 			return
-		// Exit if channel is closed otherwise send the message
-		case message, ok := <-c.sendChannel:
-			// If the send channel is closed, close the connection
-			if !ok {
-				if err := c.conn.Close(websocket.StatusNormalClosure, ""); err != nil {
-					c.logger.Error("failed to close connection", utils.ErrAttr(err))
+		// Send the next queued message
+		case message := <-c.sendChannel:
+			if err := c.write(ctx, c.messageType(), message); err != nil {
+				// A stalled write means the connection is unusable, drop the client
+				if errors.Is(err, context.DeadlineExceeded) {
+					c.logger.Warn("write timed out, closing connection", slog.Duration("timeout", c.hub.opts.WriteTimeout))
+
+					if err := c.conn.CloseNow(); err != nil {
+						c.logger.Error("failed to close connection", utils.ErrAttr(err))
+					}
+
+					return
 				}
 
-				return
+				c.logger.Error("write error", utils.ErrAttr(err))
+
+				continue
 			}
+		}
+	}
+}
 
-			// Write message with a timeout
-			writeCtx, cancel := context.WithTimeout(ctx, MAX_RESPONSE_TIMEOUT)
-			err := c.conn.Write(writeCtx, websocket.MessageText, message)
+// handleHeartbeat answers the reserved heartbeat methods, returning false for any other request.
+// A "$ping" from the client is answered with the "$pong" result, a "$pong" acknowledges the last "$ping" of the hub.
+func (c *WSClient) handleHeartbeat(ctx context.Context, req RPCRequest, slot chan RPCResponse) bool {
+	switch req.Method {
+	case HEARTBEAT_PING_METHOD:
+		if req.ID.IsAbsent() {
+			c.skip(slot)
 
-			cancel()
+			return true
+		}
 
-			if err != nil {
-				c.logger.Error("write error", utils.ErrAttr(err))
+		if err := c.sendSuccess(ctx, slot, req.ID, HEARTBEAT_PONG_METHOD); err != nil {
+			c.logger.Error("failed to send pong", utils.ErrAttr(err))
+		}
 
-				continue
+		return true
+	case HEARTBEAT_PONG_METHOD:
+		c.skip(slot)
+
+		if c.pongs != nil {
+			select {
+			case c.pongs <- struct{}{}:
+			default:
 			}
 		}
+
+		return true
+	default:
+		return false
 	}
 }
 
-func (c *WSClient) handleRequest(ctx context.Context, req RPCRequest) {
-	// Derive a logger from the original for this request
-	reqLogger := c.logger.With(slog.String("method", req.Method))
-	reqLogger = reqLogger.With(slog.String("id", req.ID.String()))
+// heartbeat sends a "$ping" notification every HeartbeatInterval and closes the connection
+// if the client does not answer with a "$pong" notification within HeartbeatTimeout.
+func (c *WSClient) heartbeat(ctx context.Context) {
+	// A notification, it has no id and the client answers with a "$pong" notification
+	ping := []byte(`{"jsonrpc":"2.0","method":"` + HEARTBEAT_PING_METHOD + `"}`)
 
-	// Get the handler
-	c.hub.methodsMutex.RLock()
-	method, exists := c.hub.methods[req.Method]
-	c.hub.methodsMutex.RUnlock()
+	ticker := time.NewTicker(c.hub.opts.HeartbeatInterval)
+	defer ticker.Stop()
 
-	if !exists {
-		if err := c.sendError(ctx, req.ID, ErrCodeNotFound, fmt.Sprintf("Method %q not found", req.Method)); err != nil {
-			reqLogger.Error("failed to send error response", utils.ErrAttr(err))
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
 		}
 
-		return
+		// Drop stale pongs, so only a pong to this ping counts
+		select {
+		case <-c.pongs:
+		default:
+		}
+
+		// Skip the ping if the send channel is full, the write pump already has work to do
+		select {
+		case c.sendChannel <- ping:
+		default:
+			c.logger.Debug("send channel full, skipping heartbeat")
+
+			continue
+		}
+
+		timer := time.NewTimer(c.hub.opts.HeartbeatTimeout)
+
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+
+			return
+		case <-c.pongs:
+			timer.Stop()
+		case <-timer.C:
+			c.logger.Info("closing client, heartbeat timed out", slog.Duration("timeout", c.hub.opts.HeartbeatTimeout))
+
+			if err := c.Close(ClosePolicyViolation.WithReason("heartbeat timeout")); err != nil {
+				c.logger.Error("failed to close connection", utils.ErrAttr(err))
+			}
+
+			return
+		}
 	}
+}
 
-	// Parse json into the structured params
-	typedParams, err := method.parser(req.Params)
-	if err != nil {
-		reqLogger.Error("unmarshal error", utils.ErrAttr(err))
+// pingLoop sends a WebSocket ping frame every PingInterval and closes the connection
+// if the client does not answer with a pong frame within WriteTimeout.
+func (c *WSClient) pingLoop(ctx context.Context) {
+	ticker := time.NewTicker(c.hub.opts.PingInterval)
+	defer ticker.Stop()
 
-		if err := c.sendError(ctx, req.ID, ErrCodeInvalidParams, fmt.Sprintf("Failed to parse params on method %q: %s", req.Method, err.Error())); err != nil {
-			reqLogger.Error("failed to send error response", utils.ErrAttr(err))
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
 		}
 
-		return
+		pingCtx, cancel := context.WithTimeout(ctx, c.hub.opts.WriteTimeout)
+		err := c.conn.Ping(pingCtx)
+
+		cancel()
+
+		if err != nil {
+			if ctx.Err() != nil {
+				return
+			}
+
+			c.logger.Info("closing client, ping failed", utils.ErrAttr(err))
+
+			if err := c.conn.CloseNow(); err != nil {
+				c.logger.Debug("failed to close connection", utils.ErrAttr(err))
+			}
+
+			return
+		}
 	}
+}
 
-	// Set a timeout for the request
-	reqCtx, cancel := context.WithTimeout(ctx, MAX_REQUEST_TIMEOUT)
-	defer cancel()
+// eventChannel returns the channel events for the client are queued on, the batch queue if the client batches events.
+func (c *WSClient) eventChannel() chan []byte {
+	if c.events != nil {
+		return c.events
+	}
 
-	// Create a new HandlerContext
-	hctx := &HandlerContext{Logger: reqLogger, WSConn: c}
+	return c.sendChannel
+}
 
-	// Call the handler
-	result, err := method.handler(reqCtx, hctx, typedParams)
-	if err != nil {
-		hctx.Logger.Error("handler error", utils.ErrAttr(err))
-		// If its a handler error, let handler specify code/message
-		var he HandlerError
-		if errors.As(err, &he) {
-			if err := c.sendError(reqCtx, req.ID, he.Code(), he.Error()); err != nil {
-				hctx.Logger.Error("failed to send error response", utils.ErrAttr(err))
+// batchPump collects the queued events of a batching client and sends them as JSON array frames.
+// A batch is flushed EventFlushInterval after its first event, or as soon as it holds MaxEventBatchSize events.
+func (c *WSClient) batchPump(ctx context.Context) {
+	batch := make([][]byte, 0, c.hub.opts.MaxEventBatchSize)
+
+	timer := time.NewTimer(c.hub.opts.EventFlushInterval)
+	timer.Stop()
+
+	defer timer.Stop()
+
+	flush := func() bool {
+		timer.Stop()
+
+		if len(batch) == 0 {
+			return true
+		}
+
+		frame := make([]byte, 0, 2+len(batch)*64)
+		frame = append(frame, '[')
+
+		for i, event := range batch {
+			if i > 0 {
+				frame = append(frame, ',')
 			}
 
+			frame = append(frame, event...)
+		}
+
+		frame = append(frame, ']')
+		batch = batch[:0]
+
+		// Wait for room, events were already accepted into the batch queue
+		select {
+		case c.sendChannel <- frame:
+			return true
+		case <-ctx.Done():
+			return false
+		}
+	}
+
+	for {
+		select {
+		case <-ctx.Done():
 			return
+		case event := <-c.events:
+			if len(batch) == 0 {
+				timer.Reset(c.hub.opts.EventFlushInterval)
+			}
+
+			batch = append(batch, event)
+			if len(batch) >= c.hub.opts.MaxEventBatchSize && !flush() {
+				return
+			}
+		case <-timer.C:
+			if !flush() {
+				return
+			}
 		}
+	}
+}
+
+// touch marks the client as active, resetting the idle timeout.
+func (c *WSClient) touch() {
+	c.lastActive.Store(time.Now().UnixNano())
+}
+
+// idleWatch closes the connection once the client has been idle for longer than the hub's IdleTimeout.
+// Closing the connection makes the readPump exit, which unregisters the client.
+func (c *WSClient) idleWatch(ctx context.Context) {
+	timeout := c.hub.opts.IdleTimeout
+
+	timer := time.NewTimer(timeout)
+	defer timer.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-timer.C:
+			idle := time.Since(time.Unix(0, c.lastActive.Load()))
+			if idle < timeout {
+				timer.Reset(timeout - idle)
+
+				continue
+			}
+
+			c.logger.Info("closing idle client", slog.Duration("idle", idle))
 
-		// Unknown errors, send internal error
-		if err := c.sendError(reqCtx, req.ID, ErrCodeInternal, fmt.Sprintf("Failed to handle request on method %q: %s", req.Method, err.Error())); err != nil {
+			if err := c.Close(ClosePolicyViolation.WithReason("idle timeout")); err != nil {
+				c.logger.Error("failed to close idle connection", utils.ErrAttr(err))
+			}
+
+			return
+		}
+	}
+}
+
+// negotiateMessageType sets the frame type of the connection on the first frame.
+// Returns false if the given frame type does not match the negotiated one.
+func (c *WSClient) negotiateMessageType(msgType websocket.MessageType) bool {
+	if c.msgType.CompareAndSwap(0, int32(msgType)) {
+		c.logger.Debug("negotiated message type", slog.String("type", msgType.String()))
+
+		return true
+	}
+
+	return c.messageType() == msgType
+}
+
+// messageType returns the frame type used for writes.
+// Both text and binary frames carry JSON, text is used until the client sends its first frame.
+func (c *WSClient) messageType() websocket.MessageType {
+	if msgType := c.msgType.Load(); msgType != 0 {
+		return websocket.MessageType(msgType)
+	}
+
+	return websocket.MessageText
+}
+
+// write writes a single message to the connection, bounded by the hub's WriteTimeout.
+// All writes to the connection must go through this method.
+func (c *WSClient) write(ctx context.Context, msgType websocket.MessageType, message []byte) error {
+	writeCtx, cancel := context.WithTimeout(ctx, c.hub.opts.WriteTimeout)
+	defer cancel()
+
+	return c.conn.Write(writeCtx, msgType, message)
+}
+
+func (c *WSClient) handleRequest(ctx context.Context, req RPCRequest, slot chan RPCResponse) {
+	// Create a new HandlerContext
+	hctx := &HandlerContext{Logger: requestLogger(c.logger, req), WSConn: c, remoteAddr: c.remoteHost, tls: c.tls, identity: c.identity}
+
+	result, rpcErr := c.hub.dispatch(ctx, hctx, req)
+
+	// Notifications get no response, not even an error
+	if req.ID.IsAbsent() {
+		c.skip(slot)
+
+		return
+	}
+
+	if rpcErr != nil {
+		if err := c.respond(ctx, slot, NewRPCResponse(req.ID, nil, rpcErr)); err != nil {
 			hctx.Logger.Error("failed to send error response", utils.ErrAttr(err))
 		}
 
 		return
 	}
 
-	if err := c.sendSuccess(reqCtx, req.ID, result); err != nil {
+	if err := c.sendSuccess(ctx, slot, req.ID, result); err != nil {
 		hctx.Logger.Error("failed to send success response", utils.ErrAttr(err))
 	}
 }
 
-func (c *WSClient) sendSuccess(ctx context.Context, id uuid.UUID, result any) error {
-	return c.sendData(ctx, NewRPCResponse(id, result, nil))
+func (c *WSClient) sendSuccess(ctx context.Context, slot chan RPCResponse, id RequestID, result any) error {
+	return c.respond(ctx, slot, NewRPCResponse(id, result, nil))
 }
 
-func (c *WSClient) sendError(ctx context.Context, id uuid.UUID, code int, message string) error {
-	return c.sendData(ctx, NewRPCResponse(id, nil, &RPCErrorObj{Code: code, Message: message}))
+func (c *WSClient) sendError(ctx context.Context, slot chan RPCResponse, id RequestID, code int, message string) error {
+	return c.respond(ctx, slot, NewRPCResponse(id, nil, &RPCErrorObj{Code: code, Message: message}))
+}
+
+// respond sends the response to a request, through its slot when responses are ordered.
+func (c *WSClient) respond(ctx context.Context, slot chan RPCResponse, r RPCResponse) error {
+	if slot == nil {
+		return c.sendData(ctx, r)
+	}
+
+	slot <- r
+
+	return nil
+}
+
+// skip releases the slot of a request that gets no response, when responses are ordered.
+func (c *WSClient) skip(slot chan RPCResponse) {
+	if slot != nil {
+		close(slot)
+	}
 }
 
 func (c *WSClient) sendData(ctx context.Context, r RPCResponse) error {
@@ -217,73 +579,242 @@ func (h *Hub) ServeWS() http.HandlerFunc {
 	wsLogger := h.logger.With(slog.String("handler", "ws"))
 
 	return func(w http.ResponseWriter, r *http.Request) {
-		conn, err := websocket.Accept(w, r, &websocket.AcceptOptions{InsecureSkipVerify: true})
+		remoteHost, err := h.remoteHost(r)
 		if err != nil {
-			wsLogger.Error("upgrade failed", utils.ErrAttr(err))
+			wsLogger.Error("failed to parse remote address", utils.ErrAttr(err), slog.String("remote_addr", r.RemoteAddr))
+			http.Error(w, "Bad request", http.StatusBadRequest)
 
 			return
 		}
 
-		// Limit the size of incoming messages
-		conn.SetReadLimit(MAX_MESSAGE_SIZE)
+		clientID, err := h.clientID(r, remoteHost)
+		if err != nil {
+			wsLogger.Warn("failed to get client ID", utils.ErrAttr(err), slog.String("remote_host", remoteHost))
+			http.Error(w, "Invalid client ID", http.StatusBadRequest)
+
+			return
+		}
 
-		remoteHost, _, err := net.SplitHostPort(r.RemoteAddr)
+		identity, err := h.identity(r)
 		if err != nil {
-			wsLogger.Error("failed to parse remote address", utils.ErrAttr(err), slog.String("remote_addr", r.RemoteAddr))
+			wsLogger.Warn("rejecting unauthenticated client", utils.ErrAttr(err), slog.String("remote_host", remoteHost))
+			http.Error(w, "Unauthorized", http.StatusUnauthorized)
 
 			return
 		}
 
-		ctx, cancel := context.WithCancel(context.Background())
+		if h.shuttingDown.Load() {
+			http.Error(w, "Server is shutting down", http.StatusServiceUnavailable)
 
-		clientID := r.URL.Query().Get("clientID")
-		if clientID == "" {
-			wsLogger.Warn("no client ID provided, generating one", slog.String("remote_addr", remoteHost))
-			clientID = fmt.Sprintf("ws-%s-%s", remoteHost, uuid.NewString())
+			return
+		}
+
+		if h.full() {
+			wsLogger.Warn("rejecting client, maximum number of clients reached", slog.String("remote_host", remoteHost), slog.Int("max_clients", h.opts.MaxClients))
+			w.Header().Set("Retry-After", "5")
+			http.Error(w, "Server is full, try again later", http.StatusServiceUnavailable)
+
+			return
+		}
+
+		conn, err := websocket.Accept(w, r, &websocket.AcceptOptions{InsecureSkipVerify: true})
+		if err != nil {
+			wsLogger.Error("upgrade failed", utils.ErrAttr(err))
+
+			return
 		}
 
+		// Limit the size of incoming messages
+		conn.SetReadLimit(MAX_MESSAGE_SIZE)
+
+		ctx, cancel := context.WithCancel(context.Background())
+
 		client := &WSClient{
 			hub:         h,
 			conn:        conn,
 			id:          clientID,
 			remoteHost:  remoteHost,
+			tls:         r.TLS,
+			identity:    identity,
 			cancel:      cancel,
 			sendChannel: make(chan []byte, MAX_QUEUED_EVENTS_PER_CLIENT),
-			logger: wsLogger.With(
-				slog.String("client_id", clientID),
-				slog.String("remote_addr", remoteHost),
-			),
+			registered:  make(chan struct{}),
+			calls:       make(map[string]chan RPCResponse),
+			// The client_id is attached on registration, once the final ID is known
+			logger: wsLogger.With(slog.String("remote_host", remoteHost)),
+		}
+
+		client.touch()
+
+		if h.opts.OrderedResponses {
+			client.ordered = make(chan chan RPCResponse, MAX_QUEUED_EVENTS_PER_CLIENT)
+		}
+
+		if h.opts.HeartbeatInterval > 0 {
+			client.pongs = make(chan struct{}, 1)
+		}
+
+		if h.opts.EventFlushInterval > 0 && wantsBatchedEvents(r) {
+			client.events = make(chan []byte, MAX_QUEUED_EVENTS_PER_CLIENT)
 		}
 
 		h.register <- client
+		// Wait for the registration to finish, it may change the client ID
+		<-client.registered
+
+		// The hub filled up or started shutting down while upgrading
+		if client.rejected {
+			cancel()
+
+			reason := CloseTryAgainLater
+			if h.shuttingDown.Load() {
+				reason = CloseGoingAway
+			}
+
+			if err := client.Close(reason); err != nil {
+				wsLogger.Debug("failed to close rejected connection", utils.ErrAttr(err))
+			}
+
+			return
+		}
+
+		if h.opts.SessionStore != nil {
+			//nolint:contextcheck
+			h.startSession(ctx, client, r.URL.Query().Get(SESSION_QUERY_PARAM))
+		}
 
 		// WebSocket lifetime is independent of HTTP upgrade request context
 		//nolint:contextcheck
 		go client.writePump(ctx)
 		//nolint:contextcheck
 		go client.readPump(ctx)
+
+		if client.ordered != nil {
+			//nolint:contextcheck
+			go client.orderedPump(ctx)
+		}
+
+		if h.opts.IdleTimeout > 0 {
+			//nolint:contextcheck
+			go client.idleWatch(ctx)
+		}
+
+		if client.pongs != nil {
+			//nolint:contextcheck
+			go client.heartbeat(ctx)
+		}
+
+		if h.opts.PingInterval > 0 {
+			//nolint:contextcheck
+			go client.pingLoop(ctx)
+		}
+
+		if client.events != nil {
+			//nolint:contextcheck
+			go client.batchPump(ctx)
+		}
 	}
 }
 
+// wantsBatchedEvents returns true if the upgrade request opts in to event batching with the "batchEvents" query parameter.
+func wantsBatchedEvents(r *http.Request) bool {
+	batch, err := strconv.ParseBool(r.URL.Query().Get("batchEvents"))
+
+	return err == nil && batch
+}
+
+// clientID returns the ID for a new client, using [HubOptions.ClientIDFunc] if set.
+// By default the "clientID" query parameter is used, falling back to a generated ID.
+func (h *Hub) clientID(r *http.Request, remoteHost string) (string, error) {
+	if h.opts.ClientIDFunc != nil {
+		clientID, err := h.opts.ClientIDFunc(r)
+		if err != nil {
+			return "", err
+		}
+
+		if clientID == "" {
+			return "", errors.New("client ID function returned an empty ID")
+		}
+
+		return clientID, nil
+	}
+
+	clientID := r.URL.Query().Get("clientID")
+	if clientID == "" {
+		h.logger.Warn("no client ID provided, generating one", slog.String("remote_host", remoteHost))
+		clientID = fmt.Sprintf("ws-%s-%s", remoteHost, uuid.NewString())
+	}
+
+	return clientID, nil
+}
+
+// clientAdmit registers a WebSocket client, unless the hub reached [HubOptions.MaxClients] or is shutting down.
+func (h *Hub) clientAdmit(client *WSClient) {
+	if h.shuttingDown.Load() {
+		client.rejected = true
+		close(client.registered)
+
+		return
+	}
+
+	if h.full() {
+		h.logger.Warn("rejecting client, maximum number of clients reached", slog.String("client_id", client.id), slog.Int("max_clients", h.opts.MaxClients))
+
+		client.rejected = true
+		close(client.registered)
+
+		return
+	}
+
+	h.clientRegister(client)
+}
+
 // clientRegister adds a new client to the hub.
+// If the client ID is already taken by a connected client, a numeric suffix is appended to it.
 func (h *Hub) clientRegister(client *WSClient) {
+	defer close(client.registered)
+
 	h.clientsMutex.Lock()
+
+	clientID := client.id
+	for i := 2; ; i++ {
+		if _, taken := h.clientIDs[clientID]; !taken {
+			break
+		}
+
+		clientID = fmt.Sprintf("%s-%d", client.id, i)
+	}
+
+	if clientID != client.id {
+		h.logger.Warn("client ID already in use, using a suffixed ID", slog.String("client_id", client.id), slog.String("new_client_id", clientID))
+	}
+
+	client.id = clientID
+	client.logger = client.logger.With(slog.String("client_id", clientID))
+
 	h.clients[client] = struct{}{}
+	h.clientIDs[clientID] = client
 	h.clientsMutex.Unlock()
 
 	h.clientCountMutex.Lock()
 	h.clientCount++
 	h.clientCountMutex.Unlock()
 
-	h.logger.Info("client registered", slog.String("client_id", client.id), slog.String("remote_host", client.remoteHost))
+	client.logger.Info("client registered")
+
+	if fn := h.connectFunc.Load(); fn != nil && *fn != nil {
+		go (*fn)(client)
+	}
 }
 
 // clientUnregister removes a client from the hub.
 func (h *Hub) clientUnregister(client *WSClient) {
 	h.clientsMutex.Lock()
 
-	if _, ok := h.clients[client]; ok {
+	_, registered := h.clients[client]
+	if registered {
 		delete(h.clients, client)
+		delete(h.clientIDs, client.id)
 
 		h.clientCountMutex.Lock()
 		h.clientCount--
@@ -291,22 +822,35 @@ func (h *Hub) clientUnregister(client *WSClient) {
 
 		h.subscriptionsMutex.Lock()
 
-		for _, subscribers := range h.subscriptions {
-			delete(subscribers, client)
+		subscriptions := slices.Sorted(maps.Keys(h.clientEvents[client]))
+		for _, event := range subscriptions {
+			delete(h.subscriptions[event], client)
 		}
 
+		delete(h.clientEvents, client)
+
 		h.subscriptionsMutex.Unlock()
+
+		// Keep the subscriptions for the client to resume, without blocking the hub on the store.
+		// The token is cleared if it was handed over to a new connection already.
+		if client.sessionToken != "" {
+			delete(h.sessionClients, client.sessionToken)
+
+			go h.saveSession(client, client.sessionToken, subscriptions)
+		}
 	}
 
 	h.clientsMutex.Unlock()
-	h.logger.Info("client disconnected", slog.String("client_id", client.id), slog.String("remote_host", client.remoteHost))
+	client.logger.Info("client disconnected")
+
+	if fn := h.disconnectFunc.Load(); registered && fn != nil && *fn != nil {
+		go (*fn)(client)
+	}
 }
 
 func (h *Hub) broadcastEvent(event RPCEvent) {
-	h.subscriptionsMutex.RLock()
-	defer h.subscriptionsMutex.RUnlock()
-
-	subscribers, ok := h.subscriptions[event.EventName]
+	// Snapshot the subscribers so the lock is not held while sending
+	subscribers, ok := h.snapshotSubscribers(event)
 	if !ok {
 		h.logger.Warn("attempted to publish to unregistered event", slog.String("event", event.EventName))
 
@@ -329,9 +873,11 @@ func (h *Hub) broadcastEvent(event RPCEvent) {
 	count := 0
 	dropped := 0
 
-	for client := range subscribers {
+	for _, client := range subscribers {
 		select {
-		case client.sendChannel <- result:
+		case client.eventChannel() <- result:
+			client.touch()
+
 			count++
 		default:
 			dropped++
@@ -347,3 +893,32 @@ func (h *Hub) broadcastEvent(event RPCEvent) {
 
 	log("event broadcast", slog.String("event", event.EventName), slog.Int("recipients", len(subscribers)), slog.Int("delivered", count), slog.Int("dropped", dropped))
 }
+
+// snapshotSubscribers returns a copy of the subscribers of an event,
+// also keeping its data as the latest snapshot if the event was registered with one.
+// The second return value is false if the event is not registered.
+func (h *Hub) snapshotSubscribers(event RPCEvent) ([]*WSClient, bool) {
+	h.subscriptionsMutex.Lock()
+	defer h.subscriptionsMutex.Unlock()
+
+	subscribers, ok := h.subscriptions[event.EventName]
+	if !ok {
+		return nil, false
+	}
+
+	if _, keep := h.snapshots[event.EventName]; keep {
+		data, err := utils.ToJSON(event.Data)
+		if err != nil {
+			h.logger.Error("failed to marshal event snapshot", slog.String("event", event.EventName), utils.ErrAttr(err))
+		} else {
+			h.snapshots[event.EventName] = data
+		}
+	}
+
+	snapshot := make([]*WSClient, 0, len(subscribers))
+	for client := range subscribers {
+		snapshot = append(snapshot, client)
+	}
+
+	return snapshot, true
+}
@@ -0,0 +1,149 @@
+package rpc
+
+import (
+	"context"
+	"encoding/json"
+	"log/slog"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/coder/websocket"
+)
+
+// pausingHandler is a slog handler that blocks on the first record with the given message until released,
+// pausing the code logging it at a known point.
+type pausingHandler struct {
+	message string
+	paused  chan struct{}
+	release chan struct{}
+	once    *sync.Once
+}
+
+func newPausingHandler(message string) *pausingHandler {
+	return &pausingHandler{message: message, paused: make(chan struct{}), release: make(chan struct{}), once: &sync.Once{}}
+}
+
+func (h *pausingHandler) Enabled(context.Context, slog.Level) bool { return true }
+
+func (h *pausingHandler) Handle(_ context.Context, r slog.Record) error {
+	if r.Message != h.message {
+		return nil
+	}
+
+	h.once.Do(func() {
+		close(h.paused)
+		<-h.release
+	})
+
+	return nil
+}
+
+func (h *pausingHandler) WithAttrs([]slog.Attr) slog.Handler { return h }
+
+func (h *pausingHandler) WithGroup(string) slog.Handler { return h }
+
+func TestSubscribeDuringBroadcast(t *testing.T) {
+	handler := newPausingHandler("send channel full, dropping event broadcast")
+	h := newTestHubWithLogger(t, slog.New(handler), HubOptions{})
+	RegisterEvent[echoResult](h, "other", EventOptions{})
+
+	// Subscribers with full send channels, so the broadcast logs (and pauses) on the first of them
+	for range 100 {
+		c := newTestWSClient(h)
+		if err := h.Subscribe(c, "ping"); err != nil {
+			t.Fatalf("Subscribe() error = %v", err)
+		}
+
+		for range cap(c.sendChannel) {
+			c.sendChannel <- []byte("{}")
+		}
+	}
+
+	h.PublishEvent(NewEvent("ping", echoResult{Message: "hello"}))
+
+	select {
+	case <-handler.paused:
+	case <-time.After(time.Second):
+		t.Fatal("broadcast did not start")
+	}
+
+	defer close(handler.release)
+
+	// The broadcast is in progress, subscribing must not wait for it
+	subscribed := make(chan error, 1)
+
+	go func() {
+		subscribed <- h.Subscribe(newTestWSClient(h), "other")
+	}()
+
+	select {
+	case err := <-subscribed:
+		if err != nil {
+			t.Fatalf("Subscribe() error = %v", err)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("Subscribe() blocked while a broadcast was in progress")
+	}
+}
+
+func TestMalformedMessageKeepsConnection(t *testing.T) {
+	h := newTestHub(t, HubOptions{})
+
+	server := httptest.NewServer(h.ServeWS())
+	defer server.Close()
+
+	ctx, cancel := context.WithTimeout(t.Context(), 5*time.Second)
+	defer cancel()
+
+	conn, _, err := websocket.Dial(ctx, "ws"+strings.TrimPrefix(server.URL, "http"), nil)
+	if err != nil {
+		t.Fatalf("Dial() error = %v", err)
+	}
+	defer conn.CloseNow()
+
+	read := func() RPCResponse {
+		t.Helper()
+
+		_, data, err := conn.Read(ctx)
+		if err != nil {
+			t.Fatalf("Read() error = %v", err)
+		}
+
+		var resp RPCResponse
+		if err := json.Unmarshal(data, &resp); err != nil {
+			t.Fatalf("failed to decode response %s: %v", data, err)
+		}
+
+		return resp
+	}
+
+	if err := conn.Write(ctx, websocket.MessageText, []byte(`{"jsonrpc":"2.0",garbage`)); err != nil {
+		t.Fatalf("Write() error = %v", err)
+	}
+
+	resp := read()
+	if resp.Error == nil || resp.Error.Code != ErrCodeParse {
+		t.Fatalf("response to garbage error = %v, want code %d", resp.Error, ErrCodeParse)
+	}
+
+	if !resp.ID.IsNull() {
+		t.Errorf("response to garbage id = %s, want null", resp.ID)
+	}
+
+	request := `{"jsonrpc":"2.0","id":"1","method":"echo","params":{"message":"still here"}}`
+	if err := conn.Write(ctx, websocket.MessageText, []byte(request)); err != nil {
+		t.Fatalf("Write() error = %v", err)
+	}
+
+	resp = read()
+	if resp.Error != nil {
+		t.Fatalf("response to valid request error = %v, want result", resp.Error)
+	}
+
+	if resp.ID.String() != "1" || string(resp.Result) != `{"message":"still here"}` {
+		t.Errorf("response to valid request = %s %s, want 1 {\"message\":\"still here\"}", resp.ID, resp.Result)
+	}
+}
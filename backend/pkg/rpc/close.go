@@ -0,0 +1,86 @@
+package rpc
+
+import (
+	"context"
+	"log/slog"
+	"maps"
+	"slices"
+	"sync"
+	"ws-json-rpc/backend/pkg/utils"
+
+	"github.com/coder/websocket"
+)
+
+// CloseReason is the WebSocket close status and reason sent when the hub closes a client,
+// so clients can tell whether to reconnect or give up.
+type CloseReason struct {
+	Code   websocket.StatusCode
+	Reason string // Human-readable reason, must fit in a control frame (at most 123 bytes)
+}
+
+var (
+	// ClosePolicyViolation closes clients breaking the rules of the hub, like the idle or heartbeat timeouts.
+	ClosePolicyViolation = CloseReason{Code: websocket.StatusPolicyViolation, Reason: "policy violation"}
+	// CloseTryAgainLater closes clients the hub can not serve right now, like when [HubOptions.MaxClients] is reached.
+	CloseTryAgainLater = CloseReason{Code: websocket.StatusTryAgainLater, Reason: "server is full, try again later"}
+	// CloseGoingAway closes clients when the hub shuts down, see [Hub.Shutdown].
+	CloseGoingAway = CloseReason{Code: websocket.StatusGoingAway, Reason: "server is shutting down"}
+	// CloseSessionResumed closes clients whose session was resumed by a new connection, see [HubOptions.SessionStore].
+	CloseSessionResumed = CloseReason{Code: websocket.StatusNormalClosure, Reason: "session resumed by another connection"}
+)
+
+// WithReason returns a copy of the close reason with another reason text, keeping its code.
+func (r CloseReason) WithReason(reason string) CloseReason {
+	r.Reason = reason
+
+	return r
+}
+
+// Close closes the connection of the client with the given close status and reason,
+// waiting for the client to acknowledge it. The client is unregistered once the connection is closed.
+// Clients without a WebSocket connection (SSE and test clients) have their stream ended instead.
+func (c *WSClient) Close(reason CloseReason) error {
+	if c.conn == nil {
+		c.cancel()
+
+		return nil
+	}
+
+	return c.conn.Close(reason.Code, reason.Reason)
+}
+
+// Shutdown closes all WebSocket clients with [CloseGoingAway] and rejects new ones.
+// It waits for the clients to acknowledge the close, or for the context to expire.
+// Call it before shutting down the HTTP server, which does not close WebSocket connections.
+func (h *Hub) Shutdown(ctx context.Context) error {
+	h.shuttingDown.Store(true)
+
+	h.clientsMutex.RLock()
+	clients := slices.Collect(maps.Keys(h.clients))
+	h.clientsMutex.RUnlock()
+
+	h.logger.Info("closing clients", slog.Int("clients", len(clients)))
+
+	var wg sync.WaitGroup
+	for _, client := range clients {
+		wg.Go(func() {
+			if err := client.Close(CloseGoingAway); err != nil {
+				client.logger.Debug("failed to close connection", utils.ErrAttr(err))
+			}
+		})
+	}
+
+	done := make(chan struct{})
+
+	go func() {
+		wg.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
@@ -0,0 +1,169 @@
+package rpc
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"ws-json-rpc/backend/pkg/utils"
+)
+
+// parseRequest decodes a request message, shared by all transports.
+// Malformed JSON yields a parse error, while valid JSON that is not a valid request yields an invalid request error.
+// The returned request carries the id whenever it could be read, so errors can be correlated by the client.
+func parseRequest(message []byte) (RPCRequest, *RPCErrorObj) {
+	var raw json.RawMessage
+	if err := json.Unmarshal(message, &raw); err != nil {
+		return RPCRequest{ID: NullID}, &RPCErrorObj{Code: ErrCodeParse, Message: fmt.Sprintf("Parse error: %s", err.Error())}
+	}
+
+	req, err := utils.FromJSON[RPCRequest](message)
+	if err != nil {
+		return RPCRequest{ID: recoverID(message)}, &RPCErrorObj{Code: ErrCodeInvalid, Message: fmt.Sprintf("Invalid request: %s", err.Error())}
+	}
+
+	if req.Version != "2.0" {
+		return req, &RPCErrorObj{Code: ErrCodeInvalid, Message: `Invalid request: jsonrpc must be exactly "2.0"`}
+	}
+
+	if req.Method == "" {
+		return req, &RPCErrorObj{Code: ErrCodeInvalid, Message: "Invalid request: method is required"}
+	}
+
+	return req, nil
+}
+
+// recoverID returns the id of an invalid request if it can be read on its own, or the null id otherwise.
+// Invalid requests are always answered, so an absent id is answered with the null id.
+func recoverID(message []byte) RequestID {
+	var partial struct {
+		ID RequestID `json:"id"`
+	}
+
+	if err := json.Unmarshal(message, &partial); err != nil || partial.ID.IsAbsent() {
+		return NullID
+	}
+
+	return partial.ID
+}
+
+// methodNotFound returns the error for an unknown method, suggesting the closest registered method if any.
+func (h *Hub) methodNotFound(name string) *RPCErrorObj {
+	suggestion, ok := h.suggestMethod(name)
+	if !ok {
+		return &RPCErrorObj{Code: ErrCodeNotFound, Message: fmt.Sprintf("Method %q not found", name)}
+	}
+
+	return &RPCErrorObj{
+		Code:    ErrCodeNotFound,
+		Message: fmt.Sprintf("Method %q not found, did you mean %q?", name, suggestion),
+		Data:    MethodNotFoundDetails{Suggestion: suggestion},
+	}
+}
+
+// requestLogger derives the logger of a request from the logger of its client, which carries the
+// client_id and remote_host, so all the logs of a client can be correlated on every transport.
+func requestLogger(l *slog.Logger, req RPCRequest) *slog.Logger {
+	return l.With(slog.String("method", req.Method), slog.String("id", req.ID.String()))
+}
+
+// dispatch resolves the method of a request, parses its params and calls its handler.
+// It is shared by all transports, so they behave identically. The returned error object
+// is nil on success. The HandlerContext must be created by the transport.
+func (h *Hub) dispatch(ctx context.Context, hctx *HandlerContext, req RPCRequest) (any, *RPCErrorObj) {
+	// Get the handler
+	method, exists := h.getMethod(req.Method)
+	if !exists {
+		return nil, h.methodNotFound(req.Method)
+	}
+
+	if rpcErr := h.authorize(hctx, req.Method, method); rpcErr != nil {
+		hctx.Logger.Warn("method call denied", slog.String("identity", hctx.identity), slog.String("group", method.group))
+
+		return nil, rpcErr
+	}
+
+	// WebSocket connections must complete the handshake before calling anything else
+	if hctx.WSConn != nil && h.requiresHandshake() {
+		if method.handshake {
+			// Claimed before calling the handler, so concurrent handshake calls can not both succeed
+			if !hctx.WSConn.claimHandshake() {
+				return nil, &RPCErrorObj{Code: ErrCodeInvalid, Message: "Connection is already initialized"}
+			}
+
+			// A failed handshake can be retried
+			defer hctx.WSConn.releaseHandshake()
+		} else if !hctx.WSConn.Initialized() {
+			return nil, &RPCErrorObj{Code: ErrCodeServerNotInitialized, Message: fmt.Sprintf("Connection is not initialized, call the handshake method before %q", req.Method)}
+		}
+	}
+
+	hctx.rawParams = req.Params
+
+	// Set a timeout for the request
+	ctx, cancel := context.WithTimeout(ctx, MAX_REQUEST_TIMEOUT)
+	defer cancel()
+
+	// Run the pre-dispatch functions, which may reject the request before its params are parsed
+	for _, fn := range method.preDispatch {
+		if err := fn(ctx, hctx, req); err != nil {
+			hctx.Logger.Warn("request rejected before dispatch", utils.ErrAttr(err))
+
+			return nil, h.handlerErrorObj(req.Method, err)
+		}
+	}
+
+	// Parse json into the structured params
+	typedParams, err := method.parser(req.Params)
+	if err != nil {
+		hctx.Logger.Error("unmarshal error", utils.ErrAttr(err))
+
+		return nil, &RPCErrorObj{Code: ErrCodeInvalidParams, Message: fmt.Sprintf("Failed to parse params on method %q: %s", req.Method, err.Error())}
+	}
+
+	// Call the handler
+	result, err := method.handler(ctx, hctx, typedParams)
+	if err != nil {
+		hctx.Logger.Error("handler error", utils.ErrAttr(err))
+
+		return nil, h.handlerErrorObj(req.Method, err)
+	}
+
+	if method.handshake && hctx.WSConn != nil {
+		hctx.WSConn.initialize(result)
+	}
+
+	return result, nil
+}
+
+// handlerErrorObj converts an error of a handler or pre-dispatch function to the error object sent to the client.
+func (h *Hub) handlerErrorObj(method string, err error) *RPCErrorObj {
+	// Error objects are sent as is, letting the handler control the whole payload
+	var rpcErr *RPCErrorObj
+	if errors.As(err, &rpcErr) {
+		return rpcErr
+	}
+
+	// If its a handler error, let handler specify code/message, and data if it has any
+	var he HandlerError
+	if errors.As(err, &he) {
+		errObj := &RPCErrorObj{Code: he.Code(), Message: he.Error()}
+		if de, ok := he.(HandlerDataError); ok {
+			errObj.Data = de.Data()
+		}
+
+		return errObj
+	}
+
+	// Unknown errors, send internal error. Only expose the error itself in dev mode.
+	if h.opts.DevMode {
+		return &RPCErrorObj{
+			Code:    ErrCodeInternal,
+			Message: fmt.Sprintf("Failed to handle request on method %q: %s", method, err.Error()),
+			Data:    ErrorDetails{Chain: errorChain(err)},
+		}
+	}
+
+	return &RPCErrorObj{Code: ErrCodeInternal, Message: fmt.Sprintf("Failed to handle request on method %q", method)}
+}
@@ -0,0 +1,84 @@
+package rpc
+
+import (
+	"context"
+	"errors"
+	"testing"
+)
+
+func TestHandshake(t *testing.T) {
+	h := newTestHub(t, HubOptions{})
+	started := make(chan struct{})
+	release := make(chan error)
+	RegisterMethod(h, "init", func(_ context.Context, _ *HandlerContext, _ struct{}) (echoResult, error) {
+		started <- struct{}{}
+		if err := <-release; err != nil {
+			return echoResult{}, err
+		}
+
+		return echoResult{Message: "ready"}, nil
+	}, RegisterMethodOptions{Handshake: true})
+
+	c := newTestClient(t, h)
+
+	// handshake starts a handshake call and waits until its handler runs
+	handshake := func() <-chan error {
+		done := make(chan error, 1)
+		go func() {
+			_, err := c.Call(t.Context(), "init", struct{}{})
+			done <- err
+		}()
+		<-started
+
+		return done
+	}
+
+	callCode := func(method string, params any) int {
+		_, err := c.Call(t.Context(), method, params)
+
+		var rpcErr *RPCErrorObj
+		if !errors.As(err, &rpcErr) {
+			t.Fatalf("Call(%q) error = %v, want *RPCErrorObj", method, err)
+		}
+
+		return rpcErr.Code
+	}
+
+	if code := callCode("echo", echoParams{Message: "hello"}); code != ErrCodeServerNotInitialized {
+		t.Errorf("Call() before the handshake error code = %d, want %d", code, ErrCodeServerNotInitialized)
+	}
+
+	// A second handshake while the first one runs is rejected
+	done := handshake()
+	if code := callCode("init", struct{}{}); code != ErrCodeInvalid {
+		t.Errorf("concurrent handshake error code = %d, want %d", code, ErrCodeInvalid)
+	}
+
+	// A failed handshake can be retried
+	release <- errors.New("not ready")
+	if err := <-done; err == nil {
+		t.Fatal("failed handshake error = nil")
+	}
+
+	if c.WSClient().Initialized() {
+		t.Fatal("Initialized() = true after a failed handshake")
+	}
+
+	done = handshake()
+	release <- nil
+	if err := <-done; err != nil {
+		t.Fatalf("handshake error = %v", err)
+	}
+
+	if !c.WSClient().Initialized() {
+		t.Fatal("Initialized() = false after the handshake")
+	}
+
+	if _, err := c.Call(t.Context(), "echo", echoParams{Message: "hello"}); err != nil {
+		t.Errorf("Call() after the handshake error = %v", err)
+	}
+
+	if code := callCode("init", struct{}{}); code != ErrCodeInvalid {
+		t.Errorf("repeated handshake error code = %d, want %d", code, ErrCodeInvalid)
+	}
+}
@@ -0,0 +1,44 @@
+package generate
+
+// This file (curl.go) builds copy-paste curl commands for the method examples,
+// calling the method over the HTTP transport.
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"ws-json-rpc/backend/pkg/utils"
+)
+
+// curlExample returns a curl command calling the method over HTTP with the example params.
+// Examples without params (ParamsObj is nil) omit the params member of the request.
+func curlExample(endpoint, method string, ex Example) (string, error) {
+	request := struct {
+		Version string          `json:"jsonrpc"`
+		ID      int             `json:"id"`
+		Method  string          `json:"method"`
+		Params  json.RawMessage `json:"params,omitempty"`
+	}{Version: "2.0", ID: 1, Method: method}
+
+	if ex.ParamsObj != nil {
+		params, err := utils.ToJSON(ex.ParamsObj)
+		if err != nil {
+			return "", fmt.Errorf("failed to marshal example params: %w", err)
+		}
+
+		request.Params = params
+	}
+
+	body, err := utils.ToJSON(request)
+	if err != nil {
+		return "", fmt.Errorf("failed to marshal example request: %w", err)
+	}
+
+	return fmt.Sprintf("curl -X POST %s \\\n  -H 'Content-Type: application/json' \\\n  -d %s",
+		shellQuote(endpoint), shellQuote(strings.TrimSpace(string(body)))), nil
+}
+
+// shellQuote quotes a string for POSIX shells using single quotes.
+func shellQuote(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+}
@@ -0,0 +1,406 @@
+package generate
+
+// This file (dbschema.go) parses the dumped SQLite database schema (CREATE TABLE statements)
+// into structured table and column metadata, so the documentation website can render it.
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"unicode"
+)
+
+// TableInfo describes a database table.
+type TableInfo struct {
+	Name        string           `json:"name"`                  // Table name
+	Columns     []ColumnInfo     `json:"columns"`               // Columns in declaration order
+	ForeignKeys []ForeignKeyInfo `json:"foreignKeys,omitempty"` // Foreign keys (column and table level)
+}
+
+// ColumnInfo describes a column of a database table.
+type ColumnInfo struct {
+	Name     string `json:"name"`              // Column name
+	Type     string `json:"type"`              // Declared type (may be empty in SQLite)
+	Nullable bool   `json:"nullable"`          // Whether the column accepts NULL
+	PK       bool   `json:"pk"`                // Whether the column is (part of) the primary key
+	Default  string `json:"default,omitempty"` // Default value expression, if any
+}
+
+// ForeignKeyInfo describes a foreign key of a database table.
+type ForeignKeyInfo struct {
+	Columns    []string `json:"columns"`    // Columns of this table
+	RefTable   string   `json:"refTable"`   // Referenced table
+	RefColumns []string `json:"refColumns"` // Referenced columns (empty means the primary key)
+}
+
+// sqlToken is a token of a SQL statement.
+type sqlToken struct {
+	text   string // Token text, quotes removed for quoted identifiers
+	quoted bool   // Whether the token was a quoted identifier or a string literal
+}
+
+// is checks case-insensitively if the token is the given unquoted keyword or punctuation.
+func (t sqlToken) is(keyword string) bool {
+	return !t.quoted && strings.EqualFold(t.text, keyword)
+}
+
+// ParseSQLiteSchema parses all CREATE TABLE statements of a SQLite schema.
+// Other statements (indexes, inserts, comments, ...) are ignored.
+func ParseSQLiteSchema(schema string) ([]TableInfo, error) {
+	tokens, err := tokenizeSQL(schema)
+	if err != nil {
+		return nil, err
+	}
+
+	tables := []TableInfo{}
+
+	for i := 0; i < len(tokens); i++ {
+		if !tokens[i].is("CREATE") {
+			continue
+		}
+
+		// CREATE [TEMP|TEMPORARY] TABLE [IF NOT EXISTS] [schema.]name (
+		j := i + 1
+		if j < len(tokens) && (tokens[j].is("TEMP") || tokens[j].is("TEMPORARY")) {
+			j++
+		}
+
+		if j >= len(tokens) || !tokens[j].is("TABLE") {
+			continue
+		}
+
+		j++
+		if j+2 < len(tokens) && tokens[j].is("IF") && tokens[j+1].is("NOT") && tokens[j+2].is("EXISTS") {
+			j += 3
+		}
+
+		if j >= len(tokens) {
+			return nil, errors.New("unexpected end of schema after CREATE TABLE")
+		}
+
+		name := tokens[j].text
+		if j+2 < len(tokens) && tokens[j+1].is(".") {
+			j += 2
+			name = tokens[j].text
+		}
+
+		j++
+		if j >= len(tokens) || !tokens[j].is("(") {
+			// CREATE TABLE ... AS SELECT, no column definitions to document
+			continue
+		}
+
+		definitions, end, err := splitDefinitions(tokens, j)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse table %q: %w", name, err)
+		}
+
+		table, err := parseTable(name, definitions)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse table %q: %w", name, err)
+		}
+
+		tables = append(tables, table)
+		i = end
+	}
+
+	return tables, nil
+}
+
+// splitDefinitions splits the parenthesized definitions starting at tokens[start] on top level commas.
+// Returns the definitions and the index of the closing parenthesis.
+func splitDefinitions(tokens []sqlToken, start int) ([][]sqlToken, int, error) {
+	var (
+		definitions [][]sqlToken
+		current     []sqlToken
+		depth       int
+	)
+
+	for i := start; i < len(tokens); i++ {
+		tok := tokens[i]
+
+		switch {
+		case tok.is("("):
+			depth++
+			if depth == 1 {
+				continue
+			}
+		case tok.is(")"):
+			depth--
+			if depth == 0 {
+				if len(current) > 0 {
+					definitions = append(definitions, current)
+				}
+
+				return definitions, i, nil
+			}
+		case tok.is(",") && depth == 1:
+			definitions = append(definitions, current)
+			current = nil
+
+			continue
+		}
+
+		current = append(current, tok)
+	}
+
+	return nil, 0, errors.New("unbalanced parentheses")
+}
+
+// parseTable builds a TableInfo from the column and table constraint definitions.
+func parseTable(name string, definitions [][]sqlToken) (TableInfo, error) {
+	table := TableInfo{Name: name, Columns: []ColumnInfo{}}
+
+	for _, def := range definitions {
+		if len(def) == 0 {
+			return TableInfo{}, errors.New("empty definition")
+		}
+
+		// Table constraints may be named: CONSTRAINT name ...
+		constraint := def
+		if constraint[0].is("CONSTRAINT") && len(constraint) > 2 {
+			constraint = constraint[2:]
+		}
+
+		switch {
+		case constraint[0].is("PRIMARY"):
+			for _, col := range parenthesizedNames(constraint, 0) {
+				for idx := range table.Columns {
+					if table.Columns[idx].Name == col {
+						table.Columns[idx].PK = true
+						table.Columns[idx].Nullable = false
+					}
+				}
+			}
+		case constraint[0].is("FOREIGN"):
+			columns := parenthesizedNames(constraint, 0)
+			if fk, ok := parseReferences(constraint, columns); ok {
+				table.ForeignKeys = append(table.ForeignKeys, fk)
+			}
+		case constraint[0].is("UNIQUE"), constraint[0].is("CHECK"):
+			// Not documented
+		default:
+			column, fk, hasFK := parseColumn(def)
+			table.Columns = append(table.Columns, column)
+
+			if hasFK {
+				table.ForeignKeys = append(table.ForeignKeys, fk)
+			}
+		}
+	}
+
+	return table, nil
+}
+
+// columnConstraintKeywords start a column constraint, ending the column type.
+var columnConstraintKeywords = []string{ //nolint:gochecknoglobals
+	"CONSTRAINT", "PRIMARY", "NOT", "NULL", "UNIQUE", "CHECK", "DEFAULT", "COLLATE", "REFERENCES", "GENERATED", "AS",
+}
+
+// parseColumn parses a column definition: name [type] [constraints...].
+func parseColumn(def []sqlToken) (ColumnInfo, ForeignKeyInfo, bool) {
+	column := ColumnInfo{Name: def[0].text, Nullable: true}
+
+	i := 1
+
+	var typeParts []string
+
+	for ; i < len(def); i++ {
+		if isConstraintKeyword(def[i]) {
+			break
+		}
+
+		typeParts = append(typeParts, def[i].text)
+	}
+
+	column.Type = strings.Join(typeParts, " ")
+	column.Type = strings.ReplaceAll(column.Type, " ( ", "(")
+	column.Type = strings.ReplaceAll(column.Type, " )", ")")
+	column.Type = strings.ReplaceAll(column.Type, " , ", ",")
+
+	var (
+		fk    ForeignKeyInfo
+		hasFK bool
+	)
+
+	for ; i < len(def); i++ {
+		switch {
+		case def[i].is("PRIMARY"):
+			column.PK = true
+			column.Nullable = false
+		case def[i].is("NOT") && i+1 < len(def) && def[i+1].is("NULL"):
+			column.Nullable = false
+			i++
+		case def[i].is("DEFAULT") && i+1 < len(def):
+			column.Default = defaultExpression(def, i+1)
+		case def[i].is("REFERENCES"):
+			fk, hasFK = parseReferences(def[i-1:], []string{column.Name})
+		}
+	}
+
+	return column, fk, hasFK
+}
+
+// isConstraintKeyword checks if a token starts a column constraint.
+func isConstraintKeyword(tok sqlToken) bool {
+	for _, keyword := range columnConstraintKeywords {
+		if tok.is(keyword) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// defaultExpression returns the default value expression starting at def[start].
+// Parenthesized expressions are kept whole, otherwise a single (possibly signed) value is returned.
+func defaultExpression(def []sqlToken, start int) string {
+	tok := def[start]
+	if tok.quoted {
+		return "'" + tok.text + "'"
+	}
+
+	if tok.is("(") {
+		var parts []string
+
+		depth := 0
+
+		for _, t := range def[start:] {
+			parts = append(parts, t.text)
+
+			if t.is("(") {
+				depth++
+			} else if t.is(")") {
+				depth--
+				if depth == 0 {
+					break
+				}
+			}
+		}
+
+		return strings.Join(parts, " ")
+	}
+
+	if (tok.is("-") || tok.is("+")) && start+1 < len(def) {
+		return tok.text + def[start+1].text
+	}
+
+	return tok.text
+}
+
+// parseReferences finds a REFERENCES clause in def and builds a foreign key for the given columns.
+func parseReferences(def []sqlToken, columns []string) (ForeignKeyInfo, bool) {
+	for i, tok := range def {
+		if !tok.is("REFERENCES") || i+1 >= len(def) {
+			continue
+		}
+
+		fk := ForeignKeyInfo{Columns: columns, RefTable: def[i+1].text, RefColumns: []string{}}
+		if i+2 < len(def) && def[i+2].is("(") {
+			fk.RefColumns = parenthesizedNames(def, i+2)
+		}
+
+		return fk, true
+	}
+
+	return ForeignKeyInfo{}, false
+}
+
+// parenthesizedNames returns the names in the first parenthesized list at or after def[start].
+func parenthesizedNames(def []sqlToken, start int) []string {
+	names := []string{}
+	inside := false
+
+	for _, tok := range def[start:] {
+		switch {
+		case tok.is("("):
+			inside = true
+		case tok.is(")"):
+			if inside {
+				return names
+			}
+		case tok.is(","):
+		case inside:
+			names = append(names, tok.text)
+		}
+	}
+
+	return names
+}
+
+// tokenizeSQL splits SQL into tokens, skipping whitespace and comments.
+// Quoted identifiers ("x", `x`, [x]) and string literals ('x') are returned unquoted.
+func tokenizeSQL(sql string) ([]sqlToken, error) {
+	var tokens []sqlToken
+
+	runes := []rune(sql)
+
+	for i := 0; i < len(runes); i++ {
+		r := runes[i]
+
+		switch {
+		case unicode.IsSpace(r):
+			continue
+		case r == '-' && i+1 < len(runes) && runes[i+1] == '-':
+			// Line comment
+			for i < len(runes) && runes[i] != '\n' {
+				i++
+			}
+		case r == '/' && i+1 < len(runes) && runes[i+1] == '*':
+			// Block comment
+			j := i + 2
+			for j+1 < len(runes) && (runes[j] != '*' || runes[j+1] != '/') {
+				j++
+			}
+
+			if j+1 >= len(runes) {
+				return nil, errors.New("unterminated block comment")
+			}
+
+			i = j + 1
+		case r == '"' || r == '`' || r == '\'' || r == '[':
+			closing := r
+			if r == '[' {
+				closing = ']'
+			}
+
+			var text strings.Builder
+
+			j := i + 1
+			for ; j < len(runes); j++ {
+				if runes[j] == closing {
+					// Doubled quotes are escaped quotes
+					if closing != ']' && j+1 < len(runes) && runes[j+1] == closing {
+						text.WriteRune(closing)
+						j++
+
+						continue
+					}
+
+					break
+				}
+
+				text.WriteRune(runes[j])
+			}
+
+			if j >= len(runes) {
+				return nil, fmt.Errorf("unterminated quote %q", string(r))
+			}
+
+			tokens = append(tokens, sqlToken{text: text.String(), quoted: true})
+			i = j
+		case unicode.IsLetter(r) || unicode.IsDigit(r) || r == '_':
+			j := i
+			for j < len(runes) && (unicode.IsLetter(runes[j]) || unicode.IsDigit(runes[j]) || runes[j] == '_' || runes[j] == '.' && unicode.IsDigit(r)) {
+				j++
+			}
+
+			tokens = append(tokens, sqlToken{text: string(runes[i:j])})
+			i = j - 1
+		default:
+			tokens = append(tokens, sqlToken{text: string(r)})
+		}
+	}
+
+	return tokens, nil
+}
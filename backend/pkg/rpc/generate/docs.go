@@ -4,7 +4,12 @@ package generate
 // including types, methods, events, and their associated metadata.
 
 import (
+	"encoding/json"
 	"errors"
+	"fmt"
+	"net/mail"
+	"net/url"
+	"os"
 	"ws-json-rpc/backend/pkg/utils"
 )
 
@@ -19,8 +24,12 @@ type FieldMetadata struct {
 	Name        string   `json:"name"`                  // Field name
 	Type        string   `json:"type"`                  // TypeScript type representation
 	Description string   `json:"description,omitempty"` // Field description from comments
-	Optional    bool     `json:"optional"`              // Whether field is optional (has ?)
+	Optional    bool     `json:"optional"`              // Whether field may be absent (has ?, from omitempty/omitzero)
+	Nullable    bool     `json:"nullable"`              // Whether field may be null (| null, from pointers/maps/slices)
+	Sensitive   bool     `json:"sensitive,omitempty"`   // Whether field holds secrets (@sensitive), masked when logging payloads
 	EnumValues  []string `json:"enumValues,omitempty"`  // Possible values if type is an enum/union
+
+	Example json.RawMessage `json:"example,omitempty"` // Example value of the field (@example, or the value of @const), used in synthesized examples
 }
 
 // UsedBy represents where a type is used (method parameter, method result, or event result).
@@ -34,6 +43,7 @@ type UsedBy struct {
 // This includes descriptions, examples, and metadata about the type structure.
 type TypeDocs struct {
 	Description        string          `json:"description"`                  // Human-readable type description
+	Package            string          `json:"package,omitempty"`            // Import path of the Go package declaring the type
 	JsonRepresentation string          `json:"jsonRepresentation,omitempty"` // Example JSON instance (only for explicitly registered types)
 	TSType             string          `json:"tsType"`                       // TypeScript type definition
 	Kind               string          `json:"kind"`                         // Type kind (e.g., "Object", "String Enum", "Union")
@@ -60,12 +70,15 @@ type ErrorDoc struct {
 
 // Example represents a sample request-response pair for a method or event.
 // The ParamsObj and ResultObj fields are used to provide actual Go objects,
-// which are then serialized to JSON strings in the Params and Result fields.
+// which are then serialized to JSON strings in the Params and Result fields, and to YAML in ParamsYAML and ResultYAML.
 type Example struct {
-	Title       string `json:"title"`       // Example name
-	Description string `json:"description"` // What this example demonstrates
-	Params      string `json:"params"`      // Serialized params JSON (set automatically)
-	Result      string `json:"result"`      // Serialized result JSON (set automatically)
+	Title       string `json:"title"`                // Example name
+	Description string `json:"description"`          // What this example demonstrates
+	Params      string `json:"params"`               // Serialized params JSON (set automatically)
+	Result      string `json:"result"`               // Serialized result JSON (set automatically)
+	ParamsYAML  string `json:"paramsYaml,omitempty"` // Params rendered as YAML (set automatically)
+	ResultYAML  string `json:"resultYaml"`           // Result rendered as YAML (set automatically)
+	Curl        string `json:"curl,omitempty"`       // curl command calling the method over HTTP (set automatically)
 
 	ResultObj any `json:"-"` // Go object for result (not serialized, used for generation)
 	ParamsObj any `json:"-"` // Go object for params (not serialized, used for generation)
@@ -74,10 +87,14 @@ type Example struct {
 // Validate ensures that the example uses the object fields (ParamsObj/ResultObj)
 // rather than the string fields (Params/Result), which are set automatically.
 func (e *Example) Validate() error {
-	if e.Params != "" || e.Result != "" {
+	if e.Params != "" || e.Result != "" || e.ParamsYAML != "" || e.ResultYAML != "" {
 		return errors.New("example should use ParamsObj and ResultObj fields instead of Params and Result strings")
 	}
 
+	if e.Curl != "" {
+		return errors.New("example curl command is set automatically")
+	}
+
 	return nil
 }
 
@@ -120,16 +137,17 @@ func (e *EventDocs) NoNilSlices() {
 // MethodDocs contains complete documentation for an RPC method.
 // Methods are bidirectional request-response calls available over HTTP and/or WebSocket.
 type MethodDocs struct {
-	Title       string     `json:"title"`       // Method name
-	Description string     `json:"description"` // Detailed description
-	Group       string     `json:"group"`       // Logical grouping (e.g., "User", "Game")
-	Tags        []string   `json:"tags"`        // Categorization tags
-	Deprecated  bool       `json:"deprecated"`  // Whether this method is deprecated
-	Protocols   Protocols  `json:"protocols"`   // Supported protocols (HTTP and/or WS)
-	ResultType  Ref        `json:"resultType"`  // Type of the response
-	ParamType   Ref        `json:"paramType"`   // Type of the request parameters
-	Examples    []Example  `json:"examples"`    // Usage examples
-	Errors      []ErrorDoc `json:"errors"`      // Possible errors
+	Title       string     `json:"title"`             // Method name
+	Description string     `json:"description"`       // Detailed description
+	Group       string     `json:"group"`             // Logical grouping (e.g., "User", "Game")
+	Tags        []string   `json:"tags"`              // Categorization tags
+	Deprecated  bool       `json:"deprecated"`        // Whether this method is deprecated
+	AliasOf     string     `json:"aliasOf,omitempty"` // Canonical method name if this method is an alias
+	Protocols   Protocols  `json:"protocols"`         // Supported protocols (HTTP and/or WS)
+	ResultType  Ref        `json:"resultType"`        // Type of the response
+	ParamType   Ref        `json:"paramType"`         // Type of the request parameters
+	Examples    []Example  `json:"examples"`          // Usage examples
+	Errors      []ErrorDoc `json:"errors"`            // Possible errors
 
 	NoHTTP bool `json:"-"` // Internal flag: if true, disable HTTP support
 }
@@ -163,9 +181,75 @@ func (m *MethodDocs) NoNilSlices() {
 
 // Info contains metadata about the API.
 type Info struct {
-	Title       string `json:"title"`       // API name
-	Version     string `json:"version"`     // API version (e.g., "1.0.0")
-	Description string `json:"description"` // API description
+	Title       string   `json:"title"`             // API name
+	Version     string   `json:"version"`           // API version (e.g., "1.0.0")
+	Description string   `json:"description"`       // API description
+	Contact     *Contact `json:"contact,omitempty"` // API contact information (omitted if empty)
+	License     *License `json:"license,omitempty"` // API license (omitted if empty)
+}
+
+// Contact contains the contact information of the API maintainers.
+type Contact struct {
+	Name  string `json:"name,omitempty"`  // Contact person/organization name
+	URL   string `json:"url,omitempty"`   // Contact URL
+	Email string `json:"email,omitempty"` // Contact email address
+}
+
+// Validate checks that the contact URL and email are well formed, when set.
+func (c *Contact) Validate() error {
+	if c.URL != "" {
+		if err := validateURL(c.URL); err != nil {
+			return fmt.Errorf("invalid contact url: %w", err)
+		}
+	}
+
+	if c.Email != "" {
+		addr, err := mail.ParseAddress(c.Email)
+		if err != nil || addr.Address != c.Email {
+			return fmt.Errorf("invalid contact email: %q", c.Email)
+		}
+	}
+
+	return nil
+}
+
+// License contains the license information of the API.
+type License struct {
+	Name string `json:"name"`          // License name (e.g., "MIT")
+	URL  string `json:"url,omitempty"` // License URL
+}
+
+// Validate checks that the license has a name and that its URL is well formed, when set.
+func (l *License) Validate() error {
+	if l.Name == "" {
+		return errors.New("license name is required")
+	}
+
+	if l.URL != "" {
+		if err := validateURL(l.URL); err != nil {
+			return fmt.Errorf("invalid license url: %w", err)
+		}
+	}
+
+	return nil
+}
+
+// validateURL checks that a URL is absolute, with an http(s) scheme and a host.
+func validateURL(rawURL string) error {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return err
+	}
+
+	if u.Scheme != "http" && u.Scheme != "https" {
+		return fmt.Errorf("url %q must use http or https", rawURL)
+	}
+
+	if u.Host == "" {
+		return fmt.Errorf("url %q must have a host", rawURL)
+	}
+
+	return nil
 }
 
 // Docs is the complete API documentation structure.
@@ -176,24 +260,96 @@ type Docs struct {
 	Events         map[string]EventDocs  `json:"events"`         // WebSocket events (event name -> docs)
 	Types          map[string]TypeDocs   `json:"types"`          // Type definitions (type name -> docs)
 	DatabaseSchema string                `json:"databaseSchema"` // SQL database schema
+	DatabaseTables []TableInfo           `json:"databaseTables"` // Tables and columns parsed from the database schema
+
+	SearchIndexFile string `json:"searchIndexFile,omitempty"` // Search index file name, relative to the docs file
 }
 
 type DocsOptions struct {
 	Title       string
 	Description string
+	Contact     Contact // Optional, omitted from the docs if empty
+	License     License // Optional, omitted from the docs if empty
+	// HTTPEndpoint is the URL of the HTTP-RPC endpoint used in the curl examples (defaults to [DEFAULT_HTTP_ENDPOINT])
+	HTTPEndpoint string
+}
+
+// Validate checks the contact and license information, when set.
+func (o *DocsOptions) Validate() error {
+	if o.Contact != (Contact{}) {
+		if err := o.Contact.Validate(); err != nil {
+			return err
+		}
+	}
+
+	if o.License != (License{}) {
+		if err := o.License.Validate(); err != nil {
+			return err
+		}
+	}
+
+	return nil
 }
 
 // NewDocs creates a new Docs instance with default values.
 // Initializes empty maps for methods, events, and types, and sets API metadata.
 func NewDocs(opt DocsOptions) *Docs {
+	info := Info{
+		Title:       opt.Title,
+		Version:     utils.GetVersionShort(),
+		Description: opt.Description,
+	}
+
+	if opt.Contact != (Contact{}) {
+		info.Contact = &opt.Contact
+	}
+
+	if opt.License != (License{}) {
+		info.License = &opt.License
+	}
+
 	return &Docs{
-		Info: Info{
-			Title:       opt.Title,
-			Version:     utils.GetVersionShort(),
-			Description: opt.Description,
-		},
+		Info:    info,
 		Methods: make(map[string]MethodDocs),
 		Events:  make(map[string]EventDocs),
 		Types:   make(map[string]TypeDocs),
 	}
 }
+
+// LoadDocs reads previously generated API documentation from a file.
+func LoadDocs(filePath string) (*Docs, error) {
+	data, err := os.ReadFile(filePath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read docs file: %w", err)
+	}
+
+	var d Docs
+	if err := json.Unmarshal(data, &d); err != nil {
+		return nil, fmt.Errorf("failed to parse docs file: %w", err)
+	}
+
+	return &d, nil
+}
+
+// SensitiveFields returns the JSON names of the fields annotated with @sensitive, keyed by the package path
+// and name of the Go type, like "example.com/app/types.User", so types of other packages with the same name
+// are told apart. Types without a known package (like in docs generated before it was recorded) are left out.
+func (d *Docs) SensitiveFields() map[string][]string {
+	sensitive := make(map[string][]string)
+
+	for typeName, typeDocs := range d.Types {
+		if typeDocs.Package == "" {
+			continue
+		}
+
+		key := typeDocs.Package + "." + typeName
+
+		for _, field := range typeDocs.Fields {
+			if field.Sensitive {
+				sensitive[key] = append(sensitive[key], field.Name)
+			}
+		}
+	}
+
+	return sensitive
+}
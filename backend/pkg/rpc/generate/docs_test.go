@@ -0,0 +1,27 @@
+package generate
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestSensitiveFields(t *testing.T) {
+	d := &Docs{Types: map[string]TypeDocs{
+		"User": {
+			Package: "example.com/types",
+			Fields:  []FieldMetadata{{Name: "name"}, {Name: "password", Sensitive: true}, {Name: "token", Sensitive: true}},
+		},
+		"Public": {
+			Package: "example.com/types",
+			Fields:  []FieldMetadata{{Name: "name"}},
+		},
+		"Unknown": {
+			Fields: []FieldMetadata{{Name: "password", Sensitive: true}},
+		},
+	}}
+
+	want := map[string][]string{"example.com/types.User": {"password", "token"}}
+	if got := d.SensitiveFields(); !reflect.DeepEqual(got, want) {
+		t.Errorf("SensitiveFields() = %v, want %v", got, want)
+	}
+}
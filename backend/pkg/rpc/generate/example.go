@@ -0,0 +1,280 @@
+package generate
+
+// This file (example.go) synthesizes default examples for methods and events
+// without explicit ones, filling the param and result types with sample values.
+
+import (
+	"bytes"
+	"encoding"
+	"encoding/json"
+	"reflect"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// SAMPLE_TIME is the timestamp used for time fields in synthesized examples.
+const SAMPLE_TIME = "2025-01-01T00:00:00Z"
+
+var (
+	timeType          = reflect.TypeFor[time.Time]()
+	jsonMarshalerType = reflect.TypeFor[json.Marshaler]()
+	textMarshalerType = reflect.TypeFor[encoding.TextMarshaler]()
+)
+
+// methodExample synthesizes an example for a method from its param and result types.
+func (g *GeneratorImpl) methodExample(req any, resp any) Example {
+	return Example{
+		Title:       "Example",
+		Description: "Generated from the param and result types",
+		ParamsObj:   g.sampleObject(req),
+		ResultObj:   g.sampleObject(resp),
+	}
+}
+
+// eventExample synthesizes an example for an event from its result type.
+func (g *GeneratorImpl) eventExample(resp any) Example {
+	return Example{
+		Title:       "Example",
+		Description: "Generated from the result type",
+		ResultObj:   g.sampleObject(resp),
+	}
+}
+
+// sampleObject returns a sample JSON value for the type of v, or nil for empty struct{} (no params/result).
+func (g *GeneratorImpl) sampleObject(v any) any {
+	if v == nil || v == struct{}{} {
+		return nil
+	}
+
+	return g.sampleValue(reflect.TypeOf(v), "", nil)
+}
+
+// sampleValue returns a representative JSON value for a type.
+// Enums use their first value, strings the JSON name of their field, numbers 1 and booleans true.
+// Slices and maps hold a single sample element. Recursive types end with null.
+// Struct fields annotated with "@example <value>" or "@const <value>" use that value instead,
+// and scalar fields with the ",string" json option are encoded as strings.
+//
+//nolint:cyclop
+func (g *GeneratorImpl) sampleValue(t reflect.Type, fieldName string, visiting []reflect.Type) any {
+	if t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+
+	if value, ok := g.sampleEnumValue(t); ok {
+		return value
+	}
+
+	if t == timeType {
+		return SAMPLE_TIME
+	}
+
+	// Types with custom encoding (e.g. UUIDs) use the encoding of their zero value
+	if reflect.PointerTo(t).Implements(jsonMarshalerType) || reflect.PointerTo(t).Implements(textMarshalerType) {
+		return json.RawMessage(marshalZero(t))
+	}
+
+	switch t.Kind() {
+	case reflect.String:
+		if fieldName == "" {
+			return "string"
+		}
+
+		return fieldName
+	case reflect.Bool:
+		return true
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Float32, reflect.Float64:
+		return 1
+	case reflect.Slice, reflect.Array:
+		// Byte slices are encoded as base64 strings
+		if t.Kind() == reflect.Slice && t.Elem().Kind() == reflect.Uint8 {
+			return ""
+		}
+
+		return []any{g.sampleValue(t.Elem(), fieldName, visiting)}
+	case reflect.Map:
+		key := "key"
+		if t.Key().Kind() != reflect.String {
+			key = "1"
+		}
+
+		return map[string]any{key: g.sampleValue(t.Elem(), fieldName, visiting)}
+	case reflect.Struct:
+		if slices.Contains(visiting, t) {
+			return nil
+		}
+
+		return g.sampleStructFields(nil, t, append(visiting, t))
+	default:
+		// Interfaces have no known shape, channels and functions can not be encoded
+		return nil
+	}
+}
+
+// sampleField is a struct field of a synthesized example.
+type sampleField struct {
+	name  string
+	value any
+}
+
+// sampleFields is a synthesized struct, encoded as a JSON object keeping the field order of the struct.
+type sampleFields []sampleField
+
+// MarshalJSON encodes the fields as a JSON object, in order.
+func (f sampleFields) MarshalJSON() ([]byte, error) {
+	var b bytes.Buffer
+
+	b.WriteByte('{')
+
+	for i, field := range f {
+		if i > 0 {
+			b.WriteByte(',')
+		}
+
+		name, err := json.Marshal(field.name)
+		if err != nil {
+			return nil, err
+		}
+
+		value, err := json.Marshal(field.value)
+		if err != nil {
+			return nil, err
+		}
+
+		b.Write(name)
+		b.WriteByte(':')
+		b.Write(value)
+	}
+
+	b.WriteByte('}')
+
+	return b.Bytes(), nil
+}
+
+// sampleStructFields appends sample values for the encoded fields of a struct, inlining embedded structs without a json name.
+func (g *GeneratorImpl) sampleStructFields(fields sampleFields, t reflect.Type, visiting []reflect.Type) sampleFields {
+	examples := g.fieldExamples(t)
+
+	for i := range t.NumField() {
+		field := t.Field(i)
+
+		tag := field.Tag.Get("json")
+		if tag == "-" {
+			continue
+		}
+
+		name, opts, _ := strings.Cut(tag, ",")
+
+		if field.Anonymous && name == "" {
+			embedded := field.Type
+			if embedded.Kind() == reflect.Pointer {
+				embedded = embedded.Elem()
+			}
+
+			if embedded.Kind() == reflect.Struct {
+				fields = g.sampleStructFields(fields, embedded, visiting)
+
+				continue
+			}
+		}
+
+		if !field.IsExported() {
+			continue
+		}
+
+		if name == "" {
+			name = field.Name
+		}
+
+		if example, ok := examples[name]; ok {
+			fields = append(fields, sampleField{name: name, value: example})
+
+			continue
+		}
+
+		value := g.sampleValue(field.Type, name, visiting)
+
+		// The ",string" option encodes scalars as JSON strings
+		if slices.Contains(strings.Split(opts, ","), "string") && isScalarKind(field.Type) {
+			if data, err := json.Marshal(value); err == nil {
+				value = string(data)
+			}
+		}
+
+		fields = append(fields, sampleField{name: name, value: value})
+	}
+
+	return fields
+}
+
+// isScalarKind returns true for the types the ",string" json option applies to: strings, numbers and booleans,
+// or pointers to them.
+func isScalarKind(t reflect.Type) bool {
+	if t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+
+	switch t.Kind() {
+	case reflect.String, reflect.Bool, reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Float32, reflect.Float64:
+		return true
+	default:
+		return false
+	}
+}
+
+// fieldExamples returns the example values of the fields of a named struct (see [FieldMetadata.Example]), keyed by JSON name.
+func (g *GeneratorImpl) fieldExamples(t reflect.Type) map[string]json.RawMessage {
+	if t.Name() == "" {
+		return nil
+	}
+
+	fields, err := g.guts.ExtractFields(t.Name())
+	if err != nil {
+		return nil
+	}
+
+	examples := make(map[string]json.RawMessage)
+
+	for _, field := range fields {
+		if field.Example != nil {
+			examples[field.Name] = field.Example
+		}
+	}
+
+	return examples
+}
+
+// sampleEnumValue returns the first value of a named enum type from the TypeScript AST.
+// Numeric enum values are returned as numbers, all others as strings.
+func (g *GeneratorImpl) sampleEnumValue(t reflect.Type) (any, bool) {
+	if t.Name() == "" || t.Kind() == reflect.Struct {
+		return nil, false
+	}
+
+	values, err := g.guts.ExtractTypeEnumValues(t.Name())
+	if err != nil || len(values) == 0 {
+		return nil, false
+	}
+
+	// Integer enums marshaled as text use their labels
+	numeric := t.Kind() != reflect.String && !reflect.PointerTo(t).Implements(textMarshalerType)
+	if n, err := strconv.ParseFloat(values[0], 64); err == nil && numeric {
+		return n, true
+	}
+
+	return values[0], true
+}
+
+// marshalZero returns the JSON encoding of the zero value of a type, or null if it can not be encoded.
+func marshalZero(t reflect.Type) []byte {
+	data, err := json.Marshal(reflect.Zero(t).Interface())
+	if err != nil {
+		return []byte("null")
+	}
+
+	return data
+}
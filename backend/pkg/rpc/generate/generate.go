@@ -2,15 +2,18 @@ package generate
 
 import (
 	"bytes"
+	"encoding/json"
 	"errors"
 	"fmt"
+	"io"
 	"log/slog"
+	"maps"
 	"os"
+	"path/filepath"
 	"reflect"
 	"slices"
 	"sort"
 	"strings"
-	"time"
 
 	"ws-json-rpc/backend/internal/database/sqlite"
 	"ws-json-rpc/backend/pkg/database"
@@ -18,7 +21,8 @@ import (
 )
 
 const (
-	NULL_TYPE_NAME = "null"
+	NULL_TYPE_NAME        = "null"
+	DEFAULT_HTTP_ENDPOINT = "http://localhost:8080/rpc"
 )
 
 // GeneratorImpl is the concrete implementation of the Generator interface.
@@ -30,16 +34,44 @@ type GeneratorImpl struct {
 	guts             *GutsGenerator // TypeScript AST parser and metadata extractor
 	docsFilePath     string         // Output path for API docs JSON
 	dbSchemaFilePath string         // Output path for database schema SQL
+	searchIndexPath  string         // Output path for the docs search index JSON (optional)
+	specPath         string         // Output path for the API spec JSON (optional)
+	specBaselinePath string         // Path of a previous API spec to check for breaking changes (optional)
+	out              *outputWriter  // Writes (or in check mode compares) the generated files
+	httpEndpoint     string         // URL of the HTTP-RPC endpoint used in the curl examples
+	requireDocs      bool           // Whether every method and event must have a title and description
+	autoExamples     bool           // Whether to synthesize an example for methods and events without one
+	warningsPath     string         // Output path for the generation warnings JSON (optional)
+	warnings         *warningCollector
 }
 
 // GeneratorOptions contains all configuration needed to create a Generator.
 // All paths must be provided for the generator to function properly.
 type GeneratorOptions struct {
-	GoTypesDirPath               string      // Path to Go types file for parsing
+	GoTypesDirPaths              []string    // Paths to the Go types directories to parse, merged into one set of types
+	ReferenceDirPaths            []string    // Paths to Go packages whose types are only generated when referenced, see [GutsOptions]
+	ExcludeTypes                 []string    // Names of types to leave out of the generated outputs, in addition to the @internal ones
+	StrictJSONTags               bool        // Fail on unexported struct fields with a json tag instead of skipping them
+	RequireDocs                  bool        // Fail generation if a method or event has no title or description
+	AutoExamples                 bool        // Synthesize an example from the types of methods and events without explicit examples
 	DocsFileOutputPath           string      // Path for generated API docs JSON file
 	TSTypesOutputPath            string      // Path for generated TypeScript types file
 	DatabaseSchemaFileOutputPath string      // Path for generated database schema SQL file
+	SearchIndexFileOutputPath    string      // Path for generated docs search index JSON file (optional)
+	SpecFileOutputPath           string      // Path for generated API spec JSON file, a signature of the API used to detect breaking changes (optional)
+	SpecBaselinePath             string      // Path of a previous API spec, generation fails on breaking changes against it (optional)
+	WarningsFileOutputPath       string      // Path for generated warnings JSON file, listing the [GenerationWarning]s for tooling (optional)
 	DocsOptions                  DocsOptions // Docs options
+
+	// Check compares the generated outputs with the existing files instead of writing them.
+	// Generate returns an error if any file is out of date, after writing a diff to CheckOutput.
+	Check       bool
+	CheckOutput io.Writer // Where diffs are written in check mode (defaults to os.Stderr)
+
+	// ValidateTypescript type-checks the generated TypeScript with tsc and fails generation on errors.
+	// Off by default, as running the compiler adds noticeable time.
+	ValidateTypescript bool
+	TSCPath            string // Path to the tsc executable (defaults to node_modules/.bin/tsc, then PATH)
 }
 
 // NewGenerator creates a Generator that validates options, initializes the TypeScript parser,
@@ -49,7 +81,8 @@ func NewGenerator(l *slog.Logger, opts GeneratorOptions) (*GeneratorImpl, error)
 	l.Debug("Creating API documentation generator",
 		slog.String("docsOutput", opts.DocsFileOutputPath),
 		slog.String("tsOutput", opts.TSTypesOutputPath),
-		slog.String("schemaOutput", opts.DatabaseSchemaFileOutputPath))
+		slog.String("schemaOutput", opts.DatabaseSchemaFileOutputPath),
+		slog.Bool("check", opts.Check))
 
 	if opts.DocsFileOutputPath == "" {
 		return nil, errors.New("docs file path is required")
@@ -59,13 +92,23 @@ func NewGenerator(l *slog.Logger, opts GeneratorOptions) (*GeneratorImpl, error)
 		return nil, errors.New("schema file path is required")
 	}
 
-	gutsGenerator, err := NewGutsGenerator(l, opts.GoTypesDirPath)
+	if err := opts.DocsOptions.Validate(); err != nil {
+		return nil, fmt.Errorf("invalid docs options: %w", err)
+	}
+
+	gutsGenerator, err := NewGutsGenerator(l, GutsOptions{
+		GoTypesDirPaths:   opts.GoTypesDirPaths,
+		ReferenceDirPaths: opts.ReferenceDirPaths,
+		ExcludeTypes:      opts.ExcludeTypes,
+		StrictJSONTags:    opts.StrictJSONTags,
+	})
 	if err != nil {
 		return nil, fmt.Errorf("failed to create GutsGenerator: %w", err)
 	}
 
-	if err := gutsGenerator.WriteTypescriptASTToFile(gutsGenerator.tsParser, opts.TSTypesOutputPath); err != nil {
-		return nil, fmt.Errorf("failed to write TypeScript AST to file: %w", err)
+	checkOutput := opts.CheckOutput
+	if checkOutput == nil {
+		checkOutput = os.Stderr
 	}
 
 	g := &GeneratorImpl{
@@ -74,8 +117,41 @@ func NewGenerator(l *slog.Logger, opts GeneratorOptions) (*GeneratorImpl, error)
 		guts:             gutsGenerator,
 		docsFilePath:     opts.DocsFileOutputPath,
 		dbSchemaFilePath: opts.DatabaseSchemaFileOutputPath,
+		searchIndexPath:  opts.SearchIndexFileOutputPath,
+		specPath:         opts.SpecFileOutputPath,
+		specBaselinePath: opts.SpecBaselinePath,
+		out:              &outputWriter{l: l, check: opts.Check, diffOutput: checkOutput},
+		httpEndpoint:     opts.DocsOptions.HTTPEndpoint,
+		requireDocs:      opts.RequireDocs,
+		autoExamples:     opts.AutoExamples,
+		warningsPath:     opts.WarningsFileOutputPath,
+	}
+
+	g.warnings = &warningCollector{l: g.l}
+
+	if g.httpEndpoint == "" {
+		g.httpEndpoint = DEFAULT_HTTP_ENDPOINT
+	}
+
+	tsTypes, err := gutsGenerator.SerializeTypescriptAST()
+	if err != nil {
+		return nil, err
+	}
+
+	if opts.ValidateTypescript {
+		if err := validateTypescript(g.l, opts.TSCPath, tsTypes); err != nil {
+			return nil, err
+		}
+
+		l.Info("Generated TypeScript type-checked successfully")
+	}
+
+	if err := g.out.write(opts.TSTypesOutputPath, []byte(tsTypes)); err != nil {
+		return nil, fmt.Errorf("failed to write TypeScript types: %w", err)
 	}
 
+	l.Info("TypeScript types written", slog.String("file", opts.TSTypesOutputPath))
+
 	l.Info("API documentation generator created successfully")
 
 	return g, nil
@@ -85,7 +161,20 @@ func NewGenerator(l *slog.Logger, opts GeneratorOptions) (*GeneratorImpl, error)
 func (g *GeneratorImpl) GetDatabaseSchema() (string, error) {
 	g.l.Debug("Generating database schema from migrations")
 
-	tempDBPath := fmt.Sprintf("%s-%d", os.TempDir(), time.Now().Unix())
+	// Migrate and dump in a temporary directory, so check mode does not touch the schema file
+	tempDir, err := os.MkdirTemp("", "ws-json-rpc-schema-")
+	if err != nil {
+		return "", fmt.Errorf("failed to create temporary directory: %w", err)
+	}
+
+	defer func() {
+		if err := os.RemoveAll(tempDir); err != nil {
+			g.l.Warn("failed to remove temporary schema directory", slog.String("dir", tempDir), utils.ErrAttr(err))
+		}
+	}()
+
+	tempDBPath := filepath.Join(tempDir, "schema.db")
+	tempSchemaPath := filepath.Join(tempDir, "schema.sql")
 
 	mig, err := database.NewMigrator(g.l, sqlite.GetMigrationsFS(), tempDBPath)
 	if err != nil {
@@ -96,15 +185,19 @@ func (g *GeneratorImpl) GetDatabaseSchema() (string, error) {
 		return "", fmt.Errorf("failed to migrate database: %w", err)
 	}
 
-	if err = mig.DumpSchema(g.dbSchemaFilePath); err != nil {
+	if err = mig.DumpSchema(tempSchemaPath); err != nil {
 		return "", fmt.Errorf("failed to dump schema: %w", err)
 	}
 
-	schemaBytes, err := os.ReadFile(g.dbSchemaFilePath)
+	schemaBytes, err := os.ReadFile(tempSchemaPath)
 	if err != nil {
 		return "", fmt.Errorf("failed to read schema file: %w", err)
 	}
 
+	if err := g.out.write(g.dbSchemaFilePath, schemaBytes); err != nil {
+		return "", fmt.Errorf("failed to write schema file: %w", err)
+	}
+
 	g.l.Info("Database schema generated", slog.String("file", g.dbSchemaFilePath))
 
 	return string(bytes.TrimSpace(schemaBytes)), nil
@@ -118,6 +211,16 @@ func (g *GeneratorImpl) Generate() error {
 		slog.Int("events", len(g.d.Events)),
 		slog.Int("types", len(g.d.Types)))
 
+	if undocumented := g.undocumented(); len(undocumented) > 0 {
+		if g.requireDocs {
+			return fmt.Errorf("undocumented methods or events: %s", strings.Join(undocumented, "\n"))
+		}
+
+		for _, problem := range undocumented {
+			g.warnings.warn("", "", problem, nil)
+		}
+	}
+
 	// Get database schema
 	schema, err := g.GetDatabaseSchema()
 	if err != nil {
@@ -126,6 +229,13 @@ func (g *GeneratorImpl) Generate() error {
 
 	g.d.DatabaseSchema = schema
 
+	tables, err := ParseSQLiteSchema(schema)
+	if err != nil {
+		return fmt.Errorf("failed to parse database schema: %w", err)
+	}
+
+	g.d.DatabaseTables = tables
+
 	// Compute back-references for all types
 	g.l.Debug("Computing type back-references")
 	g.computeBackReferences()
@@ -134,29 +244,184 @@ func (g *GeneratorImpl) Generate() error {
 	g.l.Debug("Computing type usage information")
 	g.computeUsedBy()
 
-	// Write API docs to file
-	g.l.Debug("Writing API documentation to file", slog.String("file", g.docsFilePath))
+	// Write the search index to file
+	if g.searchIndexPath != "" {
+		g.l.Debug("Writing search index to file", slog.String("file", g.searchIndexPath))
 
-	docsFile, err := os.Create(g.docsFilePath)
-	if err != nil {
-		return fmt.Errorf("failed to create api docs file: %w", err)
+		index := NewSearchIndex(g.d)
+		if err := g.writeJSONFile(g.searchIndexPath, index); err != nil {
+			return fmt.Errorf("failed to write search index: %w", err)
+		}
+
+		// Reference the index from the docs, relative to the docs file
+		g.d.SearchIndexFile = filepath.Base(g.searchIndexPath)
+
+		g.l.Info("Search index generated successfully", slog.String("file", g.searchIndexPath), slog.Int("entries", len(index.Entries)))
 	}
 
-	defer func() {
-		if err := docsFile.Close(); err != nil {
-			g.l.Error("failed to close api docs file", utils.ErrAttr(err))
+	if err := g.writeSpec(); err != nil {
+		return err
+	}
+
+	// The version embeds the VCS commit, which always differs from the checked in docs.
+	// Keep the existing version so check mode only reports actual changes.
+	if g.out.check {
+		g.d.Info.Version = existingDocsVersion(g.docsFilePath, g.d.Info.Version)
+	}
+
+	// Write the warnings to file, once all of them are collected.
+	// It is a report rather than a checked in output, so it is written in check mode too.
+	if g.warningsPath != "" {
+		warnings := g.Warnings()
+
+		var buf bytes.Buffer
+		if err := utils.ToJSONStreamIndent(&buf, warnings); err != nil {
+			return fmt.Errorf("failed to marshal generation warnings: %w", err)
 		}
-	}()
 
-	if err := utils.ToJSONStreamIndent(docsFile, g.d); err != nil {
+		if err := os.WriteFile(g.warningsPath, buf.Bytes(), 0600); err != nil {
+			return fmt.Errorf("failed to write generation warnings: %w", err)
+		}
+
+		g.l.Info("Generation warnings written", slog.String("file", g.warningsPath), slog.Int("warnings", len(warnings)))
+	}
+
+	// Write API docs to file
+	g.l.Debug("Writing API documentation to file", slog.String("file", g.docsFilePath))
+
+	if err := g.writeJSONFile(g.docsFilePath, g.d); err != nil {
 		return fmt.Errorf("failed to write api docs: %w", err)
 	}
 
+	if g.out.check {
+		return g.out.err()
+	}
+
 	g.l.Info("API documentation generated successfully", slog.String("file", g.docsFilePath))
 
 	return nil
 }
 
+// writeSpec checks the API spec against the baseline spec, if any, and writes it to file.
+// Fails on breaking changes, before writing anything, so the baseline may be the output file itself.
+func (g *GeneratorImpl) writeSpec() error {
+	if g.specPath == "" && g.specBaselinePath == "" {
+		return nil
+	}
+
+	spec := NewSpec(g.d)
+
+	if g.specBaselinePath != "" {
+		baseline, err := LoadSpec(g.specBaselinePath)
+		if err != nil {
+			return fmt.Errorf("failed to load baseline spec: %w", err)
+		}
+
+		report := CompareSpecs(baseline, &spec)
+		for _, change := range report.Changes {
+			g.l.Info("API change", slog.Bool("breaking", change.Breaking), slog.String("kind", change.Kind),
+				slog.String("name", change.Name), slog.String("change", change.Change))
+		}
+
+		if report.Breaking() {
+			return fmt.Errorf("breaking API changes against %s:\n%s", g.specBaselinePath, report)
+		}
+	}
+
+	if g.specPath == "" {
+		return nil
+	}
+
+	g.l.Debug("Writing API spec to file", slog.String("file", g.specPath))
+
+	if err := g.writeJSONFile(g.specPath, spec); err != nil {
+		return fmt.Errorf("failed to write api spec: %w", err)
+	}
+
+	return nil
+}
+
+// mustYAML renders a serialized JSON example as YAML, failing generation if it can not be converted.
+func (g *GeneratorImpl) mustYAML(jsonExample string) string {
+	out, err := jsonToYAML([]byte(jsonExample))
+	g.fatalIfErr(err)
+
+	return out
+}
+
+// writeJSONFile writes v as indented JSON to the given file, replacing it.
+func (g *GeneratorImpl) writeJSONFile(filePath string, v any) error {
+	var buf bytes.Buffer
+	if err := utils.ToJSONStreamIndent(&buf, v); err != nil {
+		return err
+	}
+
+	return g.out.write(filePath, buf.Bytes())
+}
+
+// existingDocsVersion returns the version of the existing docs file, or fallback if it cannot be read.
+func existingDocsVersion(filePath, fallback string) string {
+	data, err := os.ReadFile(filePath)
+	if err != nil {
+		return fallback
+	}
+
+	var existing struct {
+		Info struct {
+			Version string `json:"version"`
+		} `json:"info"`
+	}
+
+	if err := json.Unmarshal(data, &existing); err != nil || existing.Info.Version == "" {
+		return fallback
+	}
+
+	return existing.Info.Version
+}
+
+// undocumented lists every method and event without a title or description.
+// Aliases share the docs of their canonical method, so only the canonical method is reported.
+func (g *GeneratorImpl) undocumented() []string {
+	var problems []string
+
+	for _, name := range slices.Sorted(maps.Keys(g.d.Methods)) {
+		docs := g.d.Methods[name]
+		if docs.AliasOf != "" {
+			continue
+		}
+
+		if missing := missingDocs(docs.Title, docs.Description); missing != "" {
+			problems = append(problems, fmt.Sprintf("method %q has no %s", name, missing))
+		}
+	}
+
+	for _, name := range slices.Sorted(maps.Keys(g.d.Events)) {
+		docs := g.d.Events[name]
+		if missing := missingDocs(docs.Title, docs.Description); missing != "" {
+			problems = append(problems, fmt.Sprintf("event %q has no %s", name, missing))
+		}
+	}
+
+	return problems
+}
+
+// missingDocs describes which of the title and description are empty, or returns an empty string if neither is.
+func missingDocs(title, description string) string {
+	noTitle := strings.TrimSpace(title) == ""
+	noDescription := strings.TrimSpace(description) == ""
+
+	switch {
+	case noTitle && noDescription:
+		return "title and description"
+	case noTitle:
+		return "title"
+	case noDescription:
+		return "description"
+	default:
+		return ""
+	}
+}
+
 // AddEventType registers a WebSocket event with its response type and documentation.
 func (g *GeneratorImpl) AddEventType(name string, resp any, docs EventDocs) {
 	if _, exists := g.d.Events[name]; exists {
@@ -169,8 +434,19 @@ func (g *GeneratorImpl) AddEventType(name string, resp any, docs EventDocs) {
 		g.fatalIfErr(fmt.Errorf("failed to validate event docs: %w", err))
 	}
 
+	for _, ex := range docs.Examples {
+		if err := checkExampleType(ex.ResultObj, resp); err != nil {
+			g.fatalIfErr(fmt.Errorf("invalid result of example %q on event %q: %w", ex.Title, name, err))
+		}
+	}
+
+	if g.autoExamples && len(docs.Examples) == 0 {
+		docs.Examples = append(docs.Examples, g.eventExample(resp))
+	}
+
 	for idx, ex := range docs.Examples {
 		docs.Examples[idx].Result = string(utils.MustToJSONIndent(ex.ResultObj))
+		docs.Examples[idx].ResultYAML = g.mustYAML(docs.Examples[idx].Result)
 	}
 
 	docs.Protocols.WS = true
@@ -198,14 +474,29 @@ func (g *GeneratorImpl) AddHandlerType(name string, req any, resp any, docs Meth
 		g.fatalIfErr(fmt.Errorf("failed to validate method docs: %w", err))
 	}
 
+	if g.autoExamples && len(docs.Examples) == 0 {
+		docs.Examples = append(docs.Examples, g.methodExample(req, resp))
+	}
+
 	for idx, ex := range docs.Examples {
 		docs.Examples[idx].Result = string(utils.MustToJSONIndent(ex.ResultObj))
 		docs.Examples[idx].Params = string(utils.MustToJSONIndent(ex.ParamsObj))
+		docs.Examples[idx].ResultYAML = g.mustYAML(docs.Examples[idx].Result)
+		docs.Examples[idx].ParamsYAML = g.mustYAML(docs.Examples[idx].Params)
 	}
 
 	docs.Protocols.HTTP = !docs.NoHTTP
 	docs.Protocols.WS = true
 
+	if docs.Protocols.HTTP {
+		for idx, ex := range docs.Examples {
+			curl, err := curlExample(g.httpEndpoint, name, ex)
+			g.fatalIfErr(err)
+
+			docs.Examples[idx].Curl = curl
+		}
+	}
+
 	resultTypeName := g.mustGetTypeName(resp)
 	paramTypeName := g.mustGetTypeName(req)
 	docs.ParamType = Ref{Ref: paramTypeName}
@@ -343,6 +634,10 @@ func (g *GeneratorImpl) registerType(name string, v any) {
 		return
 	}
 
+	if g.guts.IsExcluded(name) {
+		g.fatalIfErr(fmt.Errorf("type %s is excluded from generation but used by a method or event", name))
+	}
+
 	// Check if type already exists
 	if docs, exists := g.d.Types[name]; exists {
 		// Type already registered with JSON instance, don't overwrite
@@ -367,7 +662,7 @@ func (g *GeneratorImpl) registerType(name string, v any) {
 	// Extract description from Go comments
 	description, err := g.guts.ExtractTypeDescription(name)
 	if err != nil {
-		g.l.Warn("Failed to extract description from TypeScript AST", slog.String("type", name), slog.String("error", err.Error()))
+		g.warnings.warn(name, "", "Failed to extract description from TypeScript AST", err)
 	}
 
 	// Extract TypeScript type from AST
@@ -381,6 +676,7 @@ func (g *GeneratorImpl) registerType(name string, v any) {
 
 	typeDocs := TypeDocs{
 		Description:        strings.TrimSpace(description),
+		Package:            g.guts.TypePackage(name),
 		JsonRepresentation: jsonRepresentation,
 		TSType:             tsType,
 		Kind:               metadata.kind,
@@ -415,7 +711,7 @@ func (g *GeneratorImpl) extractTypeMetadata(name string) typeMetadata {
 	// Extract type kind
 	kind, err := g.guts.ExtractTypeKind(name)
 	if err != nil {
-		g.l.Warn("Failed to extract type kind from TypeScript AST", slog.String("type", name), slog.String("error", err.Error()))
+		g.warnings.warn(name, "", "Failed to extract type kind from TypeScript AST", err)
 
 		kind = "Unknown"
 	}
@@ -425,7 +721,7 @@ func (g *GeneratorImpl) extractTypeMetadata(name string) typeMetadata {
 	// Extract field metadata
 	fields, err := g.guts.ExtractFields(name)
 	if err != nil {
-		g.l.Warn("Failed to extract fields from TypeScript AST", slog.String("type", name), slog.String("error", err.Error()))
+		g.warnings.warn(name, "", "Failed to extract fields from TypeScript AST", err)
 
 		fields = []FieldMetadata{}
 	}
@@ -435,7 +731,7 @@ func (g *GeneratorImpl) extractTypeMetadata(name string) typeMetadata {
 	// Extract references
 	references, err := g.guts.ExtractReferences(name)
 	if err != nil {
-		g.l.Warn("Failed to extract references from TypeScript AST", slog.String("type", name), slog.String("error", err.Error()))
+		g.warnings.warn(name, "", "Failed to extract references from TypeScript AST", err)
 
 		references = []string{}
 	}
@@ -445,7 +741,7 @@ func (g *GeneratorImpl) extractTypeMetadata(name string) typeMetadata {
 	// Extract type-level enum values
 	enumValues, err := g.guts.ExtractTypeEnumValues(name)
 	if err != nil {
-		g.l.Warn("Failed to extract enum values from TypeScript AST", slog.String("type", name), slog.String("error", err.Error()))
+		g.warnings.warn(name, "", "Failed to extract enum values from TypeScript AST", err)
 
 		enumValues = []string{}
 	}
@@ -492,6 +788,25 @@ func (g *GeneratorImpl) mustGetTypeName(v any) string {
 	return t.Name()
 }
 
+// checkExampleType checks that the object of an example has the registered type, or is a pointer to it.
+func checkExampleType(obj any, registered any) error {
+	want := reflect.TypeOf(registered)
+	if want != nil && want.Kind() == reflect.Pointer {
+		want = want.Elem()
+	}
+
+	got := reflect.TypeOf(obj)
+	if got != nil && got.Kind() == reflect.Pointer {
+		got = got.Elem()
+	}
+
+	if got != want {
+		return fmt.Errorf("got type %v, expected %v", got, want)
+	}
+
+	return nil
+}
+
 // isNamedStruct checks if a type is a named struct (not anonymous).
 func isNamedStruct(t reflect.Type) bool {
 	// Handle nil
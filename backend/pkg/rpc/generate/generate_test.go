@@ -0,0 +1,59 @@
+package generate
+
+import (
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestPartialResultInstantiation(t *testing.T) {
+	gutsGenerator, err := NewGutsGenerator(newTestLogger(), GutsOptions{
+		GoTypesDirPaths:   []string{"testdata/partialresult"},
+		ReferenceDirPaths: []string{".."},
+	})
+	if err != nil {
+		t.Fatalf("NewGutsGenerator() error = %v", err)
+	}
+
+	ts, err := gutsGenerator.SerializeTypescriptAST()
+	if err != nil {
+		t.Fatalf("SerializeTypescriptAST() error = %v", err)
+	}
+
+	// The named instantiation is expanded, its item errors are generated from the referenced package
+	for _, want := range []string{"export type DeleteResult = {", "results: string[];", "errors: ItemError[];", "export type ItemError = {"} {
+		if !strings.Contains(ts, want) {
+			t.Errorf("SerializeTypescriptAST() = %s, want it to contain %q", ts, want)
+		}
+	}
+
+	// Only referenced types of the reference package are generated
+	if strings.Contains(ts, "export type HubOptions") {
+		t.Error("SerializeTypescriptAST() contains the unreferenced HubOptions type")
+	}
+
+	g := &GeneratorImpl{l: newTestLogger(), d: NewDocs(DocsOptions{}), guts: gutsGenerator}
+	g.registerType("DeleteResult", nil)
+
+	result, ok := g.d.Types["DeleteResult"]
+	if !ok {
+		t.Fatal("DeleteResult is not documented")
+	}
+
+	if !slices.Equal(result.References, []string{"ItemError"}) {
+		t.Errorf("DeleteResult references = %v, want [ItemError]", result.References)
+	}
+
+	var fields []string
+	for _, field := range result.Fields {
+		fields = append(fields, field.Name+":"+field.Type)
+	}
+
+	if want := []string{"results:string[]", "errors:ItemError[]"}; !slices.Equal(fields, want) {
+		t.Errorf("DeleteResult fields = %v, want %v", fields, want)
+	}
+
+	if _, ok := g.d.Types["ItemError"]; !ok {
+		t.Error("ItemError referenced by DeleteResult is not documented")
+	}
+}
@@ -5,11 +5,19 @@ package generate
 // TypeScript type definitions with full metadata.
 
 import (
+	"encoding/json"
 	"errors"
 	"fmt"
+	"go/ast"
+	"go/token"
+	"go/types"
 	"log/slog"
+	"maps"
 	"os"
+	"reflect"
+	"slices"
 	"sort"
+	"strconv"
 	"strings"
 
 	"github.com/coder/guts"
@@ -20,35 +28,70 @@ import (
 // GutsGenerator handles TypeScript AST parsing and metadata extraction from Go types.
 type GutsGenerator struct {
 	tsParser *guts.Typescript
+	packages map[string]string // Import path of the package declaring each parsed Go type, by type name
 	vm       *bindings.Bindings
 	l        *slog.Logger
+
+	// Serialized TypeScript of every node keyed by type name, "// From" lines included.
+	// Filled once by [GutsGenerator.serializeAll], so each node does a single VM round-trip.
+	serialized map[string]string
+	order      []string // Type names in output order (sorted)
+
+	excluded map[string]bool // Names of the types excluded from generation (see [NewGutsGenerator])
+	warnings *warningCollector
+}
+
+// GutsOptions contains the configuration of a GutsGenerator.
+type GutsOptions struct {
+	GoTypesDirPaths []string // Paths to the Go types directories to parse, merged into one set of types
+	// ReferenceDirPaths are Go packages whose types are only generated when referenced by the types of
+	// GoTypesDirPaths, like the generic PartialResult of "backend/pkg/rpc".
+	ReferenceDirPaths []string
+	ExcludeTypes      []string // Names of types to leave out, in addition to the @internal ones
+	// StrictJSONTags fails on unexported struct fields with a json tag. encoding/json ignores them,
+	// so the tag usually means the field was meant to be exported. By default they are skipped silently.
+	StrictJSONTags bool
 }
 
-// NewGutsGenerator parses the Go types directory and generates a TypeScript AST for metadata extraction.
-func NewGutsGenerator(l *slog.Logger, goTypesDirPath string) (*GutsGenerator, error) {
+// NewGutsGenerator parses the Go types directories and generates a TypeScript AST for metadata extraction.
+// Types annotated with "@internal" in their doc comment, or named in ExcludeTypes, are left out of the
+// generated TypeScript and docs, as are struct fields annotated with "@internal".
+// Public types must not reference excluded ones. Struct fields annotated with "@const <value>"
+// always hold the given value, and are typed as that literal (see [applyConstAnnotations]).
+// Struct fields annotated with "@example <value>" carry that example value in their metadata (see [exampleValue]).
+// Interfaces annotated with "@union <field>" are generated as the union of the types implementing them,
+// told apart by the "@const" value of the given field (see [addDiscriminatedUnions]).
+// Integer enums keep their numeric form, unless they have a MarshalText method (see [textEnumLabels]).
+func NewGutsGenerator(l *slog.Logger, opts GutsOptions) (*GutsGenerator, error) {
 	var err error
 
 	l = l.With(slog.String("component", "guts-generator"))
 
-	// Prepend "./" to the path if it's not already there, this is
-	// to make the package parser to know that it's a local package
-	// and not a standard library package
-	goTypesDirPath = strings.TrimPrefix(goTypesDirPath, "./")
-	goTypesDirPath = strings.TrimPrefix(goTypesDirPath, "/")
-	goTypesDirPath = "./" + goTypesDirPath
+	if len(opts.GoTypesDirPaths) == 0 {
+		return nil, errors.New("at least one go types dir path is required")
+	}
+
+	dirs := localDirs(opts.GoTypesDirPaths)
 
-	l.Debug("Creating guts generator", slog.String("goTypesDirPath", goTypesDirPath))
+	l.Debug("Creating guts generator", slog.Any("goTypesDirPaths", dirs), slog.Any("referenceDirPaths", opts.ReferenceDirPaths))
 
-	gutsGenerator := &GutsGenerator{l: l}
+	gutsGenerator := &GutsGenerator{l: l, warnings: &warningCollector{l: l}}
 
 	gutsGenerator.vm, err = bindings.New()
 	if err != nil {
 		return nil, fmt.Errorf("failed to create bindings VM: %w", err)
 	}
 
-	gutsGenerator.tsParser, err = newTypescriptASTFromGoTypesDir(l, goTypesDirPath)
+	opts.GoTypesDirPaths = dirs
+	opts.ReferenceDirPaths = localDirs(opts.ReferenceDirPaths)
+
+	gutsGenerator.tsParser, gutsGenerator.packages, gutsGenerator.excluded, err = newTypescriptASTFromGoTypesDirs(l, opts)
 	if err != nil {
-		return nil, fmt.Errorf("failed to create TypeScript AST from go types dir: %w", err)
+		return nil, fmt.Errorf("failed to create TypeScript AST from go types dirs: %w", err)
+	}
+
+	if err := gutsGenerator.checkExcludedReferences(); err != nil {
+		return nil, err
 	}
 
 	l.Info("Guts generator created successfully")
@@ -56,24 +99,55 @@ func NewGutsGenerator(l *slog.Logger, goTypesDirPath string) (*GutsGenerator, er
 	return gutsGenerator, nil
 }
 
-// newTypescriptASTFromGoTypesDir creates a TypeScript AST from Go type definitions,
-// preserving comments and applying transformations for TypeScript compatibility.
-func newTypescriptASTFromGoTypesDir(l *slog.Logger, goTypesDirPath string) (*guts.Typescript, error) {
-	l.Debug("Parsing Go types directory", slog.String("path", goTypesDirPath))
+// localDirs prepends "./" to the paths if it's not already there, this is
+// to make the package parser to know that they are local packages
+// and not standard library packages.
+func localDirs(paths []string) []string {
+	dirs := make([]string, len(paths))
+	for i, dir := range paths {
+		dir = strings.TrimPrefix(dir, "./")
+		dir = strings.TrimPrefix(dir, "/")
+		dirs[i] = "./" + dir
+	}
+
+	return dirs
+}
 
+// newTypescriptASTFromGoTypesDirs creates a TypeScript AST from the Go type definitions of the given directories,
+// preserving comments and applying transformations for TypeScript compatibility.
+// The types of all directories are merged, so type names must be unique across them.
+// Also returns the import path of the package declaring each Go type by type name, and the names of the types
+// excluded from the AST, see [NewGutsGenerator].
+func newTypescriptASTFromGoTypesDirs(l *slog.Logger, opts GutsOptions) (*guts.Typescript, map[string]string, map[string]bool, error) {
 	goParser, err := guts.NewGolangParser()
 	if err != nil {
-		return nil, fmt.Errorf("failed to create guts parser: %w", err)
+		return nil, nil, nil, fmt.Errorf("failed to create guts parser: %w", err)
 	}
 
 	goParser.PreserveComments()
 
-	if _, err := os.Stat(goTypesDirPath); os.IsNotExist(err) {
-		return nil, fmt.Errorf("go types dir path %s does not exist", goTypesDirPath)
+	for _, dir := range opts.GoTypesDirPaths {
+		l.Debug("Parsing Go types directory", slog.String("path", dir))
+
+		if _, err := os.Stat(dir); os.IsNotExist(err) {
+			return nil, nil, nil, fmt.Errorf("go types dir path %s does not exist", dir)
+		}
+
+		if err := goParser.IncludeGenerate(dir); err != nil {
+			return nil, nil, nil, fmt.Errorf("failed to include go types dir %s for parsing: %w", dir, err)
+		}
 	}
 
-	if err := goParser.IncludeGenerate(goTypesDirPath); err != nil {
-		return nil, fmt.Errorf("failed to include go types dir for parsing: %w", err)
+	for _, dir := range opts.ReferenceDirPaths {
+		l.Debug("Parsing Go reference directory", slog.String("path", dir))
+
+		if _, err := os.Stat(dir); os.IsNotExist(err) {
+			return nil, nil, nil, fmt.Errorf("go reference dir path %s does not exist", dir)
+		}
+
+		if err := goParser.IncludeReference(dir, ""); err != nil {
+			return nil, nil, nil, fmt.Errorf("failed to include go reference dir %s for parsing: %w", dir, err)
+		}
 	}
 
 	hasErrors := false
@@ -89,64 +163,729 @@ func newTypescriptASTFromGoTypesDir(l *slog.Logger, goTypesDirPath string) (*gut
 	}
 
 	if hasErrors {
-		return nil, errors.New("failed to parse go types")
+		return nil, nil, nil, errors.New("failed to parse go types")
+	}
+
+	if err := checkTypeNameCollisions(goParser); err != nil {
+		return nil, nil, nil, err
+	}
+
+	if opts.StrictJSONTags {
+		if err := checkUnexportedJSONTags(goParser); err != nil {
+			return nil, nil, nil, err
+		}
+	}
+
+	excluded, err := excludeTypesFromParser(l, goParser, opts.ExcludeTypes)
+	if err != nil {
+		return nil, nil, nil, err
 	}
 
 	l.Debug("Generating TypeScript AST from Go types")
 
 	ts, err := goParser.ToTypescript()
 	if err != nil {
-		return nil, fmt.Errorf("failed to generate TypeScript AST: %w", err)
+		return nil, nil, nil, fmt.Errorf("failed to generate TypeScript AST: %w", err)
+	}
+
+	unions, err := discriminatedUnions(goParser, excluded)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+
+	labels, err := textEnumLabels(goParser)
+	if err != nil {
+		return nil, nil, nil, err
 	}
 
 	ts.ApplyMutations(
+		excludeInternalFields,
+		jsonStringOption(jsonStringFields(goParser)),
+		textEnumsAsStrings(labels),
 		config.EnumAsTypes,
 		config.EnumLists,
+	)
+
+	// Applied once enums are unions, so const values can be checked against them, and before
+	// interfaces become type aliases
+	if err := applyConstAnnotations(ts); err != nil {
+		return nil, nil, nil, err
+	}
+
+	if err := addDiscriminatedUnions(ts, goParser, unions); err != nil {
+		return nil, nil, nil, err
+	}
+
+	if err := checkExampleAnnotations(ts); err != nil {
+		return nil, nil, nil, err
+	}
+
+	ts.ApplyMutations(
 		config.ExportTypes,
 		config.InterfaceToType,
 	)
 
 	l.Debug("TypeScript AST generated successfully")
 
-	return ts, nil
+	return ts, typePackages(goParser), excluded, nil
 }
 
-// WriteTypescriptASTToFile serializes and writes TypeScript type definitions to a file.
-func (g *GutsGenerator) WriteTypescriptASTToFile(ts *guts.Typescript, filePath string) error {
-	g.l.Debug("Serializing TypeScript AST", slog.String("file", filePath))
+// typePackages maps the names of the parsed Go types to the import path of their package.
+func typePackages(goParser *guts.GoParser) map[string]string {
+	packages := make(map[string]string)
 
-	str, err := ts.Serialize()
-	if err != nil {
-		return fmt.Errorf("failed to serialize TypeScript AST: %w", err)
+	for _, pkg := range goParser.Pkgs {
+		if pkg.Types == nil {
+			continue
+		}
+
+		scope := pkg.Types.Scope()
+		for _, name := range scope.Names() {
+			if _, ok := scope.Lookup(name).(*types.TypeName); ok {
+				packages[name] = pkg.PkgPath
+			}
+		}
 	}
 
-	err = os.WriteFile(filePath, []byte(str), 0600)
-	if err != nil {
-		return fmt.Errorf("failed to write TypeScript AST to file: %w", err)
+	return packages
+}
+
+// excludeTypesFromParser excludes the types annotated with "@internal" and the types named in excludeTypes
+// from generation, returning the excluded type names. Unknown names in excludeTypes are an error.
+func excludeTypesFromParser(l *slog.Logger, goParser *guts.GoParser, excludeTypes []string) (map[string]bool, error) {
+	excluded := make(map[string]bool)
+	for _, name := range excludeTypes {
+		excluded[name] = true
+	}
+
+	found := make(map[string]bool)
+
+	var qualified []string
+
+	for _, pkg := range goParser.Pkgs {
+		internal := internalTypeNames(pkg.Syntax)
+
+		scope := pkg.Types.Scope()
+		for _, name := range scope.Names() {
+			if _, ok := scope.Lookup(name).(*types.TypeName); !ok {
+				continue
+			}
+
+			if !excluded[name] && !internal[name] {
+				continue
+			}
+
+			excluded[name] = true
+			found[name] = true
+
+			qualified = append(qualified, pkg.PkgPath+"."+name)
+			l.Debug("Excluding type from generation", slog.String("type", name))
+		}
+	}
+
+	for _, name := range excludeTypes {
+		if !found[name] {
+			return nil, fmt.Errorf("excluded type %s not found in the go types dirs", name)
+		}
+	}
+
+	if err := goParser.ExcludeCustom(qualified...); err != nil {
+		return nil, fmt.Errorf("failed to exclude types: %w", err)
+	}
+
+	return excluded, nil
+}
+
+// internalTypeNames returns the names of the type declarations annotated with "@internal" in their doc comment.
+func internalTypeNames(files []*ast.File) map[string]bool {
+	names := make(map[string]bool)
+
+	for name, doc := range typeDocComments(files) {
+		if _, internal := cutAnnotation(doc, "internal"); internal {
+			names[name] = true
+		}
+	}
+
+	return names
+}
+
+// typeDocComments returns the doc comments of the type declarations, keyed by type name.
+func typeDocComments(files []*ast.File) map[string]string {
+	docs := make(map[string]string)
+
+	for _, file := range files {
+		for _, decl := range file.Decls {
+			gen, ok := decl.(*ast.GenDecl)
+			if !ok || gen.Tok != token.TYPE {
+				continue
+			}
+
+			for _, spec := range gen.Specs {
+				typeSpec, ok := spec.(*ast.TypeSpec)
+				if !ok {
+					continue
+				}
+
+				// A lone spec in a declaration has its doc comment on the declaration
+				doc := typeSpec.Doc
+				if doc == nil && len(gen.Specs) == 1 {
+					doc = gen.Doc
+				}
+
+				if doc != nil {
+					docs[typeSpec.Name.Name] = doc.Text()
+				}
+			}
+		}
+	}
+
+	return docs
+}
+
+// textEnumLabels finds the integer enums marshaled as text, which are strings on the wire instead of numbers.
+// An integer type is marshaled as text if it has a MarshalText method, all its constants must then declare
+// their wire form with a "@label <value>" annotation. Returns the labels keyed by type and constant name.
+func textEnumLabels(goParser *guts.GoParser) (map[string]map[string]string, error) {
+	labels := make(map[string]map[string]string)
+
+	var errs []error
+
+	pkgPaths := slices.Sorted(maps.Keys(goParser.Pkgs))
+	for _, pkgPath := range pkgPaths {
+		pkg := goParser.Pkgs[pkgPath]
+		if pkg.Types == nil {
+			continue
+		}
+
+		for _, file := range pkg.Syntax {
+			for _, decl := range file.Decls {
+				gen, ok := decl.(*ast.GenDecl)
+				if !ok || gen.Tok != token.CONST {
+					continue
+				}
+
+				for _, spec := range gen.Specs {
+					valueSpec, ok := spec.(*ast.ValueSpec)
+					if !ok {
+						continue
+					}
+
+					for _, ident := range valueSpec.Names {
+						obj, ok := pkg.TypesInfo.Defs[ident].(*types.Const)
+						if !ok || !isTextMarshaledInteger(obj.Type()) {
+							continue
+						}
+
+						typeName := obj.Type().(*types.Named).Obj().Name()
+
+						label, ok := constLabel(valueSpec)
+						if !ok {
+							errs = append(errs, fmt.Errorf("%s: constant %s of %s is marshaled as text, annotate it with @label <value>",
+								pkg.Fset.Position(ident.Pos()), ident.Name, typeName))
+
+							continue
+						}
+
+						if labels[typeName] == nil {
+							labels[typeName] = make(map[string]string)
+						}
+
+						labels[typeName][ident.Name] = label
+					}
+				}
+			}
+		}
+	}
+
+	return labels, errors.Join(errs...)
+}
+
+// isTextMarshaledInteger checks if a type is a named integer type with a MarshalText method.
+func isTextMarshaledInteger(t types.Type) bool {
+	named, ok := t.(*types.Named)
+	if !ok {
+		return false
+	}
+
+	basic, ok := named.Underlying().(*types.Basic)
+	if !ok || basic.Info()&types.IsInteger == 0 {
+		return false
+	}
+
+	return types.NewMethodSet(types.NewPointer(named)).Lookup(nil, "MarshalText") != nil
+}
+
+// constLabel returns the value of the "@label" annotation of a constant, from its doc or line comment.
+func constLabel(spec *ast.ValueSpec) (string, bool) {
+	for _, group := range []*ast.CommentGroup{spec.Doc, spec.Comment} {
+		if group == nil {
+			continue
+		}
+
+		if _, label, ok := cutAnnotationValue(group.Text(), "label"); ok && label != "" {
+			return constString(label), true
+		}
+	}
+
+	return "", false
+}
+
+// textEnumsAsStrings returns a mutation that replaces the numeric values of the given enums with their labels.
+func textEnumsAsStrings(labels map[string]map[string]string) guts.MutationFunc {
+	return func(ts *guts.Typescript) {
+		ts.ForEach(func(_ string, node bindings.Node) {
+			enum, ok := node.(*bindings.Enum)
+			if !ok || labels[enum.Name.Name] == nil {
+				return
+			}
+
+			for _, member := range enum.Members {
+				if label, ok := labels[enum.Name.Name][member.Name]; ok {
+					member.Value = &bindings.LiteralType{Value: label}
+				}
+			}
+		})
+	}
+}
+
+// excludeInternalFields removes the struct fields annotated with "@internal" from the TypeScript AST.
+func excludeInternalFields(ts *guts.Typescript) {
+	ts.ForEach(func(_ string, node bindings.Node) {
+		intf, ok := node.(*bindings.Interface)
+		if !ok {
+			return
+		}
+
+		intf.Fields = slices.DeleteFunc(intf.Fields, func(field *bindings.PropertySignature) bool {
+			for _, comment := range field.Comments() {
+				if _, internal := cutAnnotation(comment.Text, "internal"); internal {
+					return true
+				}
+			}
+
+			return false
+		})
+	})
+}
+
+// applyConstAnnotations changes the TypeScript type of the struct fields annotated with "@const <value>"
+// to the literal value, like a discriminator `kind: "user"`. The value is a bare word or a JSON string
+// for string fields (including string enums, which must contain it), and a number for number fields.
+func applyConstAnnotations(ts *guts.Typescript) error {
+	var errs []error
+
+	ts.ForEach(func(_ string, node bindings.Node) {
+		intf, ok := node.(*bindings.Interface)
+		if !ok {
+			return
+		}
+
+		for _, field := range intf.Fields {
+			for _, comment := range field.Comments() {
+				_, value, ok := cutAnnotationValue(comment.Text, "const")
+				if !ok {
+					continue
+				}
+
+				literal, err := constLiteral(ts, field.Type, value)
+				if err != nil {
+					errs = append(errs, fmt.Errorf("invalid @const on field %s.%s: %w", intf.Name.Name, field.Name, err))
+
+					break
+				}
+
+				field.Type = literal
+
+				break
+			}
+		}
+	})
+
+	return errors.Join(errs...)
+}
+
+// checkExampleAnnotations returns an error for every struct field annotated with an "@example <value>"
+// that does not fit the type of the field, so invalid examples fail generation instead of being dropped.
+func checkExampleAnnotations(ts *guts.Typescript) error {
+	var errs []error
+
+	ts.ForEach(func(_ string, node bindings.Node) {
+		intf, ok := node.(*bindings.Interface)
+		if !ok {
+			return
+		}
+
+		for _, field := range intf.Fields {
+			for _, comment := range field.Comments() {
+				_, value, ok := cutAnnotationValue(comment.Text, "example")
+				if !ok {
+					continue
+				}
+
+				if _, err := exampleValue(ts, field.Type, value); err != nil {
+					errs = append(errs, fmt.Errorf("invalid @example on field %s.%s: %w", intf.Name.Name, field.Name, err))
+				}
+			}
+		}
+	})
+
+	return errors.Join(errs...)
+}
+
+// constLiteral parses the value of a "@const" annotation as a literal of the given field type.
+func constLiteral(ts *guts.Typescript, fieldType bindings.ExpressionType, value string) (*bindings.LiteralType, error) {
+	if value == "" {
+		return nil, errors.New("missing value")
+	}
+
+	switch t := fieldType.(type) {
+	case *bindings.LiteralKeyword:
+		switch *t {
+		case bindings.KeywordString:
+			return &bindings.LiteralType{Value: constString(value)}, nil
+		case bindings.KeywordNumber:
+			if i, err := strconv.ParseInt(value, 10, 64); err == nil {
+				return &bindings.LiteralType{Value: i}, nil
+			}
+
+			f, err := strconv.ParseFloat(value, 64)
+			if err != nil {
+				return nil, fmt.Errorf("value %q is not a number", value)
+			}
+
+			return &bindings.LiteralType{Value: f}, nil
+		}
+	case *bindings.ReferenceType:
+		// String enums are aliases of string literal unions at this point
+		node, ok := ts.Node(t.Name.String())
+		if !ok {
+			break
+		}
+
+		alias, ok := node.(*bindings.Alias)
+		if !ok {
+			break
+		}
+
+		union, ok := alias.Type.(*bindings.UnionType)
+		if !ok {
+			break
+		}
+
+		s := constString(value)
+		for _, member := range union.Types {
+			if lit, ok := member.(*bindings.LiteralType); ok && lit.Value == s {
+				return &bindings.LiteralType{Value: s}, nil
+			}
+		}
+
+		return nil, fmt.Errorf("value %q is not a member of %s", s, t.Name.String())
+	}
+
+	return nil, errNotLiteralType
+}
+
+// errNotLiteralType is returned by constLiteral for fields whose type has no literal values.
+var errNotLiteralType = errors.New("only string, number and string enum fields can be const")
+
+// exampleValue parses the value of an "@example" annotation as a JSON value of the given field type.
+// String, number and string enum fields take the same values as "@const", boolean fields true or false,
+// and all other fields a JSON value without spaces, like [1,2] for an array.
+func exampleValue(ts *guts.Typescript, fieldType bindings.ExpressionType, value string) (json.RawMessage, error) {
+	if value == "" {
+		return nil, errors.New("missing value")
+	}
+
+	if keyword, ok := fieldType.(*bindings.LiteralKeyword); ok && *keyword == bindings.KeywordBoolean {
+		b, err := strconv.ParseBool(value)
+		if err != nil {
+			return nil, fmt.Errorf("value %q is not a boolean", value)
+		}
+
+		return json.Marshal(b)
+	}
+
+	literal, err := constLiteral(ts, fieldType, value)
+	if err == nil {
+		return json.Marshal(literal.Value)
+	}
+
+	if !errors.Is(err, errNotLiteralType) {
+		return nil, err
+	}
+
+	if !json.Valid([]byte(value)) {
+		return nil, fmt.Errorf("value %q is not valid JSON", value)
 	}
 
-	g.l.Info("TypeScript types written", slog.String("file", filePath))
+	return json.RawMessage(value), nil
+}
+
+// constString returns the string value of a "@const" annotation, unquoting JSON strings.
+func constString(value string) string {
+	var s string
+	if err := json.Unmarshal([]byte(value), &s); err == nil {
+		return s
+	}
+
+	return value
+}
+
+// checkExcludedReferences returns an error for every generated type referencing an excluded type.
+func (g *GutsGenerator) checkExcludedReferences() error {
+	var errs []error
+
+	g.tsParser.ForEach(func(name string, node bindings.Node) {
+		refs := make(map[string]struct{})
+		g.collectTypeReferences(node, refs)
+
+		for ref := range refs {
+			if g.excluded[ref] {
+				errs = append(errs, withLocation(node, fmt.Errorf("type %s references the excluded type %s", name, ref)))
+			}
+		}
+	})
+
+	// Map iteration order is random, sort for deterministic errors
+	slices.SortFunc(errs, func(a, b error) int { return strings.Compare(a.Error(), b.Error()) })
+
+	return errors.Join(errs...)
+}
+
+// IsExcluded returns true if the type was excluded from generation.
+func (g *GutsGenerator) IsExcluded(name string) bool {
+	return g.excluded[name]
+}
+
+// checkTypeNameCollisions returns an error for every type name declared by more than one of the parsed packages,
+// as the generated TypeScript and docs refer to types by their unqualified name.
+func checkTypeNameCollisions(goParser *guts.GoParser) error {
+	declaredIn := make(map[string]string)
+
+	var errs []error
+
+	// Visit the packages in a stable order, so the reported collisions are deterministic
+	pkgPaths := slices.Sorted(maps.Keys(goParser.Pkgs))
+	for _, pkgPath := range pkgPaths {
+		pkg := goParser.Pkgs[pkgPath]
+		if pkg.Types == nil {
+			continue
+		}
+
+		scope := pkg.Types.Scope()
+		for _, name := range scope.Names() {
+			if _, ok := scope.Lookup(name).(*types.TypeName); !ok {
+				continue
+			}
+
+			if other, exists := declaredIn[name]; exists {
+				errs = append(errs, fmt.Errorf("type %s is declared in both %s and %s", name, other, pkg.PkgPath))
+
+				continue
+			}
+
+			declaredIn[name] = pkg.PkgPath
+		}
+	}
+
+	return errors.Join(errs...)
+}
+
+// checkUnexportedJSONTags returns an error for every unexported struct field with a json tag.
+// Embedded structs are allowed, as encoding/json promotes the fields of unexported embedded structs.
+func checkUnexportedJSONTags(goParser *guts.GoParser) error {
+	var errs []error
+
+	pkgPaths := slices.Sorted(maps.Keys(goParser.Pkgs))
+	for _, pkgPath := range pkgPaths {
+		pkg := goParser.Pkgs[pkgPath]
+		if pkg.Types == nil {
+			continue
+		}
+
+		scope := pkg.Types.Scope()
+		for _, name := range scope.Names() {
+			st, ok := scope.Lookup(name).Type().Underlying().(*types.Struct)
+			if !ok {
+				continue
+			}
+
+			for i := range st.NumFields() {
+				field := st.Field(i)
+				if field.Exported() || field.Anonymous() {
+					continue
+				}
+
+				tag, ok := reflect.StructTag(st.Tag(i)).Lookup("json")
+				if !ok || tag == "-" {
+					continue
+				}
+
+				errs = append(errs, fmt.Errorf("%s: unexported field %s.%s has a json tag, but is never encoded (export it or remove the tag)",
+					pkg.Fset.Position(field.Pos()), name, field.Name()))
+			}
+		}
+	}
+
+	return errors.Join(errs...)
+}
+
+// jsonStringFields finds numeric and boolean struct fields tagged with the `json:",string"` option,
+// which encoding/json encodes as JSON strings. Returns the JSON field names keyed by Go type name.
+func jsonStringFields(goParser *guts.GoParser) map[string]map[string]bool {
+	fields := make(map[string]map[string]bool)
+
+	for _, pkg := range goParser.Pkgs {
+		if pkg.Types == nil {
+			continue
+		}
+
+		scope := pkg.Types.Scope()
+		for _, name := range scope.Names() {
+			st, ok := scope.Lookup(name).Type().Underlying().(*types.Struct)
+			if !ok {
+				continue
+			}
+
+			for i := range st.NumFields() {
+				field := st.Field(i)
+
+				parts := strings.Split(reflect.StructTag(st.Tag(i)).Get("json"), ",")
+				if !field.Exported() || parts[0] == "-" || !slices.Contains(parts[1:], "string") || !isStringEncodable(field.Type()) {
+					continue
+				}
+
+				jsonName := parts[0]
+				if jsonName == "" {
+					jsonName = field.Name()
+				}
+
+				if fields[name] == nil {
+					fields[name] = make(map[string]bool)
+				}
+
+				fields[name][jsonName] = true
+			}
+		}
+	}
+
+	return fields
+}
+
+// isStringEncodable checks if the `json:",string"` option changes the JSON type of a Go type,
+// which is the case for (pointers to) numeric and boolean types.
+func isStringEncodable(t types.Type) bool {
+	if ptr, ok := t.(*types.Pointer); ok {
+		t = ptr.Elem()
+	}
+
+	basic, ok := t.Underlying().(*types.Basic)
+
+	return ok && basic.Info()&(types.IsNumeric|types.IsBoolean) != 0
+}
+
+// jsonStringOption returns a mutation that changes the TypeScript type of the given fields to string,
+// as guts ignores the `json:",string"` option and would emit number/boolean.
+func jsonStringOption(fields map[string]map[string]bool) guts.MutationFunc {
+	return func(ts *guts.Typescript) {
+		ts.ForEach(func(_ string, node bindings.Node) {
+			intf, ok := node.(*bindings.Interface)
+			if !ok || fields[intf.Name.Name] == nil {
+				return
+			}
+
+			for _, field := range intf.Fields {
+				if fields[intf.Name.Name][field.Name] {
+					field.Type = stringifyKeywords(field.Type)
+				}
+			}
+		})
+	}
+}
+
+// stringifyKeywords replaces number and boolean keywords with string, including inside unions (e.g. `number | null`).
+func stringifyKeywords(expr bindings.ExpressionType) bindings.ExpressionType {
+	switch t := expr.(type) {
+	case *bindings.LiteralKeyword:
+		if *t == bindings.KeywordNumber || *t == bindings.KeywordBoolean {
+			keyword := bindings.KeywordString
+
+			return &keyword
+		}
+	case *bindings.UnionType:
+		for i := range t.Types {
+			t.Types[i] = stringifyKeywords(t.Types[i])
+		}
+	}
+
+	return expr
+}
+
+// serializeAll serializes every node of the TypeScript AST once, indexing the output by type name.
+// Both the full TypeScript file and the per-type representations are built from the index,
+// instead of serializing each type again (and guts creating a second VM for the whole file).
+func (g *GutsGenerator) serializeAll() error {
+	if g.serialized != nil {
+		return nil
+	}
+
+	g.l.Debug("Serializing TypeScript AST")
+
+	nodes := make(map[string]bindings.Node)
+	g.tsParser.ForEach(func(name string, node bindings.Node) {
+		nodes[name] = node
+	})
+
+	g.order = slices.Sorted(maps.Keys(nodes))
+	serialized := make(map[string]string, len(nodes))
+
+	for _, name := range g.order {
+		node := nodes[name]
+
+		typescriptNode, err := g.vm.ToTypescriptNode(node)
+		if err != nil {
+			return withLocation(node, fmt.Errorf("failed to convert node %s to TypeScript: %w", name, err))
+		}
+
+		text, err := g.vm.SerializeToTypescript(typescriptNode)
+		if err != nil {
+			return withLocation(node, fmt.Errorf("failed to serialize node %s to TypeScript: %w", name, err))
+		}
+
+		serialized[name] = text
+	}
+
+	g.serialized = serialized
 
 	return nil
 }
 
-// SerializeNode converts a type name to its TypeScript string representation.
-func (g *GutsGenerator) SerializeNode(name string) (string, error) {
-	g.l.Debug("Serializing node", slog.String("type", name))
+// SerializeTypescriptAST serializes the TypeScript type definitions, in the same format as guts.
+func (g *GutsGenerator) SerializeTypescriptAST() (string, error) {
+	if err := g.serializeAll(); err != nil {
+		return "", fmt.Errorf("failed to serialize TypeScript AST: %w", err)
+	}
 
-	node, exists := g.tsParser.Node(name)
-	if !exists {
-		return "", fmt.Errorf("node %s not found in TypeScript AST", name)
+	var str strings.Builder
+
+	str.WriteString("// Code generated by 'guts'. DO NOT EDIT.\n\n")
+
+	for _, name := range g.order {
+		str.WriteString(g.serialized[name] + "\n\n")
 	}
 
-	typescriptNode, err := g.vm.ToTypescriptNode(node)
-	if err != nil {
-		return "", fmt.Errorf("failed to convert node to TypeScript: %w", err)
+	return str.String(), nil
+}
+
+// SerializeNode returns the TypeScript representation of a type, without the "// From" comment.
+func (g *GutsGenerator) SerializeNode(name string) (string, error) {
+	if err := g.serializeAll(); err != nil {
+		return "", fmt.Errorf("failed to serialize TypeScript AST: %w", err)
 	}
 
-	serializedNode, err := g.vm.SerializeToTypescript(typescriptNode)
-	if err != nil {
-		return "", fmt.Errorf("failed to serialize node to TypeScript: %w", err)
+	serializedNode, exists := g.serialized[name]
+	if !exists {
+		return "", fmt.Errorf("node %s not found in TypeScript AST", name)
 	}
 
 	var str strings.Builder
@@ -162,6 +901,37 @@ func (g *GutsGenerator) SerializeNode(name string) (string, error) {
 	return strings.TrimSpace(str.String()), nil
 }
 
+// nodeLocation returns the "file:line" of the Go declaration a node was generated from, or "" if unknown.
+func nodeLocation(node bindings.Node) string {
+	var source bindings.Source
+
+	switch n := node.(type) {
+	case *bindings.Alias:
+		source = n.Source
+	case *bindings.Interface:
+		source = n.Source
+	case *bindings.VariableStatement:
+		source = n.Source
+	default:
+		return ""
+	}
+
+	if source.File == "" {
+		return ""
+	}
+
+	return fmt.Sprintf("%s:%d", source.File, source.Position.Line)
+}
+
+// withLocation prefixes err with the Go source location of node, when known.
+func withLocation(node bindings.Node, err error) error {
+	if location := nodeLocation(node); location != "" {
+		return fmt.Errorf("%s: %w", location, err)
+	}
+
+	return err
+}
+
 // ExtractReferences returns all type names referenced by the given type, deduplicated and sorted.
 func (g *GutsGenerator) ExtractReferences(name string) ([]string, error) {
 	node, exists := g.tsParser.Node(name)
@@ -198,7 +968,7 @@ func (g *GutsGenerator) ExtractFields(name string) ([]FieldMetadata, error) {
 	switch n := node.(type) {
 	case *bindings.Alias:
 		// Type alias - extract fields from the aliased type if it's a type literal
-		fields = g.extractFieldsFromExpressionType(n.Type)
+		fields = g.extractFieldsFromExpressionType(name, n.Type)
 
 	case *bindings.Interface:
 		// Interface - extract fields from property signatures
@@ -207,16 +977,15 @@ func (g *GutsGenerator) ExtractFields(name string) ([]FieldMetadata, error) {
 			if err != nil {
 				g.l.Warn("Failed to serialize field type", slog.String("type", name), slog.String("field", prop.Name), slog.String("error", err.Error()))
 
-				return nil, fmt.Errorf("failed to serialize type for field %s in %s: %w", prop.Name, name, err)
+				return nil, withLocation(node, fmt.Errorf("failed to serialize type for field %s in %s: %w", prop.Name, name, err))
+			}
+
+			field, err := g.fieldMetadata(prop, typeStr)
+			if err != nil {
+				return nil, withLocation(node, fmt.Errorf("invalid field %s in %s: %w", prop.Name, name, err))
 			}
 
-			fields = append(fields, FieldMetadata{
-				Name:        prop.Name,
-				Type:        typeStr,
-				Description: g.extractComments(prop.SupportComments),
-				Optional:    prop.QuestionToken,
-				EnumValues:  g.extractEnumValues(prop.Type),
-			})
+			fields = append(fields, field)
 		}
 	}
 
@@ -225,6 +994,11 @@ func (g *GutsGenerator) ExtractFields(name string) ([]FieldMetadata, error) {
 	return fields, nil
 }
 
+// TypePackage returns the import path of the Go package declaring a type, or "" if it was not parsed from Go.
+func (g *GutsGenerator) TypePackage(name string) string {
+	return g.packages[name]
+}
+
 // ExtractTypeDescription extracts the description from a type's comments.
 func (g *GutsGenerator) ExtractTypeDescription(name string) (string, error) {
 	node, exists := g.tsParser.Node(name)
@@ -240,7 +1014,7 @@ func (g *GutsGenerator) ExtractTypeDescription(name string) (string, error) {
 		return g.extractComments(n.SupportComments), nil
 
 	default:
-		return "", fmt.Errorf("node %s is not a supported type (%T)", name, node)
+		return "", withLocation(node, fmt.Errorf("node %s is not a supported type (%T)", name, node))
 	}
 }
 
@@ -255,7 +1029,7 @@ func (g *GutsGenerator) ExtractTypeKind(name string) (string, error) {
 	case *bindings.Alias:
 		kind, err := g.getTypeKindFromExpression(n.Type)
 		if err != nil {
-			return "", fmt.Errorf("failed to get type kind for alias %s: %w", name, err)
+			return "", withLocation(node, fmt.Errorf("failed to get type kind for alias %s: %w", name, err))
 		}
 
 		g.l.Debug("Extracted type kind", slog.String("type", name), slog.String("kind", kind))
@@ -268,7 +1042,7 @@ func (g *GutsGenerator) ExtractTypeKind(name string) (string, error) {
 		return "Object", nil
 
 	default:
-		return "", fmt.Errorf("node %s is not a supported type (%T)", name, node)
+		return "", withLocation(node, fmt.Errorf("node %s is not a supported type (%T)", name, node))
 	}
 }
 
@@ -289,7 +1063,7 @@ func (g *GutsGenerator) ExtractTypeEnumValues(name string) ([]string, error) {
 
 		return values, nil
 	default:
-		return nil, fmt.Errorf("node %s is not a supported type (%T)", name, node)
+		return nil, withLocation(node, fmt.Errorf("node %s is not a supported type (%T)", name, node))
 	}
 }
 
@@ -381,7 +1155,7 @@ func (g *GutsGenerator) getTypeKindFromExpression(expr bindings.ExpressionType)
 
 // extractFieldsFromExpressionType extracts fields from type literals.
 // Returns nil if not a type literal. Skips fields that fail serialization with a warning.
-func (g *GutsGenerator) extractFieldsFromExpressionType(expr bindings.ExpressionType) []FieldMetadata {
+func (g *GutsGenerator) extractFieldsFromExpressionType(name string, expr bindings.ExpressionType) []FieldMetadata {
 	typeLiteral, ok := expr.(*bindings.TypeLiteralNode)
 	if !ok {
 		return nil
@@ -392,23 +1166,107 @@ func (g *GutsGenerator) extractFieldsFromExpressionType(expr bindings.Expression
 	for _, member := range typeLiteral.Members {
 		typeStr, err := g.serializeExpressionType(member.Type)
 		if err != nil {
-			g.l.Warn("Failed to serialize field type in type literal", slog.String("field", member.Name), slog.String("error", err.Error()))
+			g.warnings.warn(name, member.Name, "Failed to serialize field type in type literal", err)
 
 			continue
 		}
 
-		fields = append(fields, FieldMetadata{
-			Name:        member.Name,
-			Type:        typeStr,
-			Description: g.extractComments(member.SupportComments),
-			Optional:    member.QuestionToken,
-			EnumValues:  g.extractEnumValues(member.Type),
-		})
+		field, err := g.fieldMetadata(member, typeStr)
+		if err != nil {
+			g.warnings.warn(name, member.Name, "Invalid field in type literal", err)
+
+			continue
+		}
+
+		fields = append(fields, field)
 	}
 
 	return fields
 }
 
+// fieldMetadata builds the metadata of a property, parsing the annotations of its comments.
+// Returns an error if the value of an "@example" annotation does not fit the type of the field.
+func (g *GutsGenerator) fieldMetadata(prop *bindings.PropertySignature, typeStr string) (FieldMetadata, error) {
+	description, sensitive := cutAnnotation(g.extractComments(prop.SupportComments), "sensitive")
+	description, _, _ = cutAnnotationValue(description, "const")
+	description, exampleText, hasExample := cutAnnotationValue(description, "example")
+
+	var example json.RawMessage
+
+	if hasExample {
+		var err error
+
+		example, err = exampleValue(g.tsParser, prop.Type, exampleText)
+		if err != nil {
+			return FieldMetadata{}, fmt.Errorf("invalid @example: %w", err)
+		}
+	}
+
+	// Fields annotated with @const always hold their literal value
+	if literal, ok := prop.Type.(*bindings.LiteralType); ok {
+		if data, err := json.Marshal(literal.Value); err == nil {
+			example = data
+		}
+	}
+
+	return FieldMetadata{
+		Name:        prop.Name,
+		Type:        typeStr,
+		Description: description,
+		Optional:    prop.QuestionToken,
+		Nullable:    isNullable(prop.Type),
+		Sensitive:   sensitive,
+		EnumValues:  g.extractEnumValues(prop.Type),
+		Example:     example,
+	}, nil
+}
+
+// cutAnnotation removes the "@name" annotation from a comment,
+// returning the remaining text and whether the annotation was present.
+func cutAnnotation(text, name string) (string, bool) {
+	words := strings.Fields(text)
+	found := false
+
+	kept := words[:0]
+	for _, word := range words {
+		if word == "@"+name {
+			found = true
+
+			continue
+		}
+
+		kept = append(kept, word)
+	}
+
+	if !found {
+		return text, false
+	}
+
+	return strings.Join(kept, " "), true
+}
+
+// cutAnnotationValue removes the "@name <value>" annotation from a comment,
+// returning the remaining text, the value and whether the annotation was present.
+// The value is the word following the annotation, empty if there is none.
+func cutAnnotationValue(text, name string) (string, string, bool) {
+	words := strings.Fields(text)
+
+	i := slices.Index(words, "@"+name)
+	if i < 0 {
+		return text, "", false
+	}
+
+	value := ""
+	end := i + 1
+
+	if end < len(words) {
+		value = words[end]
+		end++
+	}
+
+	return strings.Join(slices.Delete(words, i, end), " "), value, true
+}
+
 // serializeExpressionType converts an expression type to its TypeScript string representation.
 func (g *GutsGenerator) serializeExpressionType(expr bindings.ExpressionType) (string, error) {
 	if expr == nil {
@@ -461,6 +1319,15 @@ func (g *GutsGenerator) extractEnumValues(expr bindings.ExpressionType) []string
 		return g.extractLiteralsFromUnion(union)
 	}
 
+	// Check if it's a single string literal (like a @const field)
+	if lit, ok := expr.(*bindings.LiteralType); ok {
+		if s, ok := lit.Value.(string); ok {
+			return []string{s}
+		}
+
+		return nil
+	}
+
 	// Check if it's a reference to another type (like EventKind)
 	ref, ok := expr.(*bindings.ReferenceType)
 	if !ok {
@@ -486,6 +1353,27 @@ func (g *GutsGenerator) extractEnumValues(expr bindings.ExpressionType) []string
 	return g.extractLiteralsFromUnion(union)
 }
 
+// isNullable checks if an expression type accepts null, i.e. it is a union with a null member.
+// This is independent of the field being optional, a nullable field is still required to be present.
+func isNullable(expr bindings.ExpressionType) bool {
+	union, ok := expr.(*bindings.UnionType)
+	if !ok {
+		return false
+	}
+
+	for _, member := range union.Types {
+		if _, ok := member.(*bindings.Null); ok {
+			return true
+		}
+
+		if keyword, ok := member.(*bindings.LiteralKeyword); ok && string(*keyword) == "NullKeyword" {
+			return true
+		}
+	}
+
+	return false
+}
+
 // extractLiteralsFromUnion extracts string literal values from a union, ignoring other types.
 func (g *GutsGenerator) extractLiteralsFromUnion(union *bindings.UnionType) []string {
 	var values []string
@@ -552,8 +1440,12 @@ func (g *GutsGenerator) collectExpressionTypeReferences(expr bindings.Expression
 			g.collectExpressionTypeReferences(member, refs)
 		}
 
-	case *bindings.ArrayLiteralType:
+	case *bindings.ArrayType:
 		// Array: T[]
+		g.collectExpressionTypeReferences(e.Node, refs)
+
+	case *bindings.ArrayLiteralType:
+		// Tuple: [A, B]
 		for _, elem := range e.Elements {
 			g.collectExpressionTypeReferences(elem, refs)
 		}
@@ -0,0 +1,110 @@
+package generate
+
+import (
+	"io"
+	"log/slog"
+	"strings"
+	"testing"
+
+	"github.com/coder/guts/bindings"
+)
+
+func newTestLogger() *slog.Logger {
+	return slog.New(slog.NewTextHandler(io.Discard, nil))
+}
+
+func TestErrorsIncludeLocation(t *testing.T) {
+	t.Run("excluded reference", func(t *testing.T) {
+		_, err := NewGutsGenerator(newTestLogger(), GutsOptions{GoTypesDirPaths: []string{"testdata/excludedref"}})
+		if err == nil {
+			t.Fatal("NewGutsGenerator() error = nil, want excluded reference error")
+		}
+
+		if want := "excludedref/types.go:11:"; !strings.Contains(err.Error(), want) {
+			t.Errorf("NewGutsGenerator() error = %q, want it to contain %q", err, want)
+		}
+	})
+
+	t.Run("unsupported node", func(t *testing.T) {
+		g, err := NewGutsGenerator(newTestLogger(), GutsOptions{GoTypesDirPaths: []string{"testdata/constant"}})
+		if err != nil {
+			t.Fatalf("NewGutsGenerator() error = %v", err)
+		}
+
+		_, err = g.ExtractTypeKind("Limit")
+		if err == nil {
+			t.Fatal("ExtractTypeKind() error = nil, want unsupported type error")
+		}
+
+		if want := "constant/types.go:4:"; !strings.Contains(err.Error(), want) {
+			t.Errorf("ExtractTypeKind() error = %q, want it to contain %q", err, want)
+		}
+	})
+}
+
+// newBenchGutsGenerator creates a GutsGenerator from the API types of the server.
+func newBenchGutsGenerator(b *testing.B) *GutsGenerator {
+	b.Helper()
+
+	g, err := NewGutsGenerator(newTestLogger(), GutsOptions{GoTypesDirPaths: []string{"../../../internal/rpcapi/types"}})
+	if err != nil {
+		b.Fatalf("NewGutsGenerator() error = %v", err)
+	}
+
+	return g
+}
+
+// BenchmarkSerializeIndexed serializes the full TypeScript file and every type from the index built by serializeAll.
+func BenchmarkSerializeIndexed(b *testing.B) {
+	g := newBenchGutsGenerator(b)
+
+	for b.Loop() {
+		g.serialized = nil
+
+		if _, err := g.SerializeTypescriptAST(); err != nil {
+			b.Fatal(err)
+		}
+
+		for _, name := range g.order {
+			if _, err := g.SerializeNode(name); err != nil {
+				b.Fatal(err)
+			}
+		}
+	}
+}
+
+// BenchmarkSerializePerNode serializes the same output as BenchmarkSerializeIndexed the way it was done before
+// the index: guts serializes the full file on a VM of its own, and every type is converted again on ours.
+// guts serializes an AST only once, so every iteration parses the types again, outside of the timer.
+func BenchmarkSerializePerNode(b *testing.B) {
+	for range b.N {
+		b.StopTimer()
+
+		g := newBenchGutsGenerator(b)
+
+		var names []string
+
+		g.tsParser.ForEach(func(name string, _ bindings.Node) {
+			names = append(names, name)
+		})
+
+		b.StartTimer()
+
+		if _, err := g.tsParser.Serialize(); err != nil {
+			b.Fatal(err)
+		}
+
+		for _, name := range names {
+			node, _ := g.tsParser.Node(name)
+
+			typescriptNode, err := g.vm.ToTypescriptNode(node)
+			if err != nil {
+				b.Fatal(err)
+			}
+
+			if _, err := g.vm.SerializeToTypescript(typescriptNode); err != nil {
+				b.Fatal(err)
+			}
+		}
+	}
+}
@@ -0,0 +1,289 @@
+package generate
+
+// This file (output.go) handles writing generated files. In check mode nothing is written,
+// instead the outputs are compared against the existing files and a diff is reported for stale ones.
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"log/slog"
+	"os"
+	"strings"
+)
+
+// diffContextLines is the number of unchanged lines shown around each change.
+const diffContextLines = 3
+
+// outputWriter writes generated files, or compares them against the existing files in check mode.
+type outputWriter struct {
+	l          *slog.Logger
+	check      bool      // Compare instead of writing
+	diffOutput io.Writer // Where diffs of stale files are written in check mode
+	stale      []string  // Files that differ from the generated output (check mode only)
+}
+
+// write writes data to filePath, or in check mode compares it with the existing file.
+func (o *outputWriter) write(filePath string, data []byte) error {
+	if !o.check {
+		if err := os.WriteFile(filePath, data, 0600); err != nil {
+			return fmt.Errorf("failed to write file: %w", err)
+		}
+
+		return nil
+	}
+
+	existing, err := os.ReadFile(filePath)
+	if err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return fmt.Errorf("failed to read file: %w", err)
+	}
+
+	if bytes.Equal(existing, data) {
+		o.l.Info("Generated file is up to date", slog.String("file", filePath))
+
+		return nil
+	}
+
+	o.l.Warn("Generated file is out of date", slog.String("file", filePath))
+	o.stale = append(o.stale, filePath)
+
+	diff := unifiedDiff(filePath, string(existing), string(data))
+	if _, err := io.WriteString(o.diffOutput, diff); err != nil {
+		return fmt.Errorf("failed to write diff: %w", err)
+	}
+
+	return nil
+}
+
+// err returns an error listing the stale files, if any were found in check mode.
+func (o *outputWriter) err() error {
+	if len(o.stale) == 0 {
+		return nil
+	}
+
+	return fmt.Errorf("%d generated file(s) are out of date: %s", len(o.stale), strings.Join(o.stale, ", "))
+}
+
+// unifiedDiff returns a unified diff (with hunk context) between the existing and the generated content.
+func unifiedDiff(filePath, oldText, newText string) string {
+	oldLines := splitLines(oldText)
+	newLines := splitLines(newText)
+	ops := diffLines(oldLines, newLines)
+
+	var sb strings.Builder
+
+	fmt.Fprintf(&sb, "--- %s (existing)\n+++ %s (generated)\n", filePath, filePath)
+
+	// Group the operations into hunks, keeping diffContextLines of unchanged lines around changes
+	for start := 0; start < len(ops); {
+		// Find the next change
+		first := start
+		for first < len(ops) && ops[first].kind == ' ' {
+			first++
+		}
+
+		if first == len(ops) {
+			break
+		}
+
+		// Extend the hunk while changes are close to each other
+		last := first
+		for i := first; i < len(ops); i++ {
+			if ops[i].kind != ' ' {
+				last = i
+			} else if i-last > 2*diffContextLines {
+				break
+			}
+		}
+
+		hunkStart := max(first-diffContextLines, start)
+		hunkEnd := min(last+diffContextLines+1, len(ops))
+
+		oldStart, newStart := ops[hunkStart].oldLine, ops[hunkStart].newLine
+		oldCount, newCount := 0, 0
+
+		for _, op := range ops[hunkStart:hunkEnd] {
+			if op.kind != '+' {
+				oldCount++
+			}
+
+			if op.kind != '-' {
+				newCount++
+			}
+		}
+
+		fmt.Fprintf(&sb, "@@ -%d,%d +%d,%d @@\n", oldStart+1, oldCount, newStart+1, newCount)
+
+		for _, op := range ops[hunkStart:hunkEnd] {
+			sb.WriteByte(op.kind)
+			sb.WriteString(op.text)
+			sb.WriteByte('\n')
+		}
+
+		start = hunkEnd
+	}
+
+	return sb.String()
+}
+
+// diffOp is a single line of a diff.
+type diffOp struct {
+	kind    byte   // ' ' unchanged, '-' removed, '+' added
+	text    string // Line content
+	oldLine int    // Index into the old lines at this operation
+	newLine int    // Index into the new lines at this operation
+}
+
+// diffLines computes a minimal line diff, in linear space (see [appendDiff]).
+func diffLines(oldLines, newLines []string) []diffOp {
+	return appendDiff(make([]diffOp, 0, max(len(oldLines), len(newLines))), oldLines, newLines, 0, 0)
+}
+
+// appendDiff appends the diff of oldLines and newLines, which start at the given line indexes, to ops.
+// The common prefix and suffix are trimmed first, as generated files usually differ in a few places.
+// The rest is split at the middle of a shortest edit script (see [diffSplit]) and diffed recursively.
+func appendDiff(ops []diffOp, oldLines, newLines []string, oldLine, newLine int) []diffOp {
+	for len(oldLines) > 0 && len(newLines) > 0 && oldLines[0] == newLines[0] {
+		ops = append(ops, diffOp{kind: ' ', text: oldLines[0], oldLine: oldLine, newLine: newLine})
+		oldLines, newLines = oldLines[1:], newLines[1:]
+		oldLine++
+		newLine++
+	}
+
+	suffix := 0
+	for suffix < len(oldLines) && suffix < len(newLines) && oldLines[len(oldLines)-1-suffix] == newLines[len(newLines)-1-suffix] {
+		suffix++
+	}
+
+	common := oldLines[len(oldLines)-suffix:]
+	oldLines, newLines = oldLines[:len(oldLines)-suffix], newLines[:len(newLines)-suffix]
+
+	x, y, ok := 0, 0, false
+	if len(oldLines) > 0 && len(newLines) > 0 {
+		x, y, ok = diffSplit(oldLines, newLines)
+	}
+
+	if ok {
+		ops = appendDiff(ops, oldLines[:x], newLines[:y], oldLine, newLine)
+		ops = appendDiff(ops, oldLines[x:], newLines[y:], oldLine+x, newLine+y)
+	} else {
+		for k, line := range oldLines {
+			ops = append(ops, diffOp{kind: '-', text: line, oldLine: oldLine + k, newLine: newLine})
+		}
+
+		for k, line := range newLines {
+			ops = append(ops, diffOp{kind: '+', text: line, oldLine: oldLine + len(oldLines), newLine: newLine + k})
+		}
+	}
+
+	oldLine += len(oldLines)
+	newLine += len(newLines)
+
+	for k, line := range common {
+		ops = append(ops, diffOp{kind: ' ', text: line, oldLine: oldLine + k, newLine: newLine + k})
+	}
+
+	return ops
+}
+
+// diffSplit finds where the forward and backward searches for a shortest edit script of a and b meet (Myers' bisection),
+// taking O((N+M)D) time and linear space. Returns the meeting point, or false if a and b have no line in common.
+//
+//nolint:cyclop
+func diffSplit(a, b []string) (int, int, bool) {
+	n, m := len(a), len(b)
+	maxD := (n + m + 1) / 2
+	offset := maxD
+
+	// forward[offset+k] is the furthest x reached on diagonal k = x-y from the start,
+	// backward[offset+k] the same from the end, on the reversed lines
+	forward := make([]int, 2*maxD+2)
+	backward := make([]int, 2*maxD+2)
+
+	for i := range forward {
+		forward[i], backward[i] = -1, -1
+	}
+
+	forward[offset+1], backward[offset+1] = 0, 0
+
+	delta := n - m
+	// With an odd delta the searches meet during a forward step, else during a backward one
+	front := delta%2 != 0
+
+	// Diagonals that ran off the grid are no longer searched
+	forwardStart, forwardEnd, backwardStart, backwardEnd := 0, 0, 0, 0
+
+	for d := range maxD {
+		for k := -d + forwardStart; k <= d-forwardEnd; k += 2 {
+			var x int
+			if k == -d || (k != d && forward[offset+k-1] < forward[offset+k+1]) {
+				x = forward[offset+k+1]
+			} else {
+				x = forward[offset+k-1] + 1
+			}
+
+			y := x - k
+			for x < n && y < m && a[x] == b[y] {
+				x++
+				y++
+			}
+
+			forward[offset+k] = x
+
+			switch {
+			case x > n:
+				forwardEnd += 2
+			case y > m:
+				forwardStart += 2
+			case front:
+				if kb := offset + delta - k; kb >= 0 && kb < len(backward) && backward[kb] != -1 && x >= n-backward[kb] {
+					return x, y, true
+				}
+			}
+		}
+
+		for k := -d + backwardStart; k <= d-backwardEnd; k += 2 {
+			var x int
+			if k == -d || (k != d && backward[offset+k-1] < backward[offset+k+1]) {
+				x = backward[offset+k+1]
+			} else {
+				x = backward[offset+k-1] + 1
+			}
+
+			y := x - k
+			for x < n && y < m && a[n-1-x] == b[m-1-y] {
+				x++
+				y++
+			}
+
+			backward[offset+k] = x
+
+			switch {
+			case x > n:
+				backwardEnd += 2
+			case y > m:
+				backwardStart += 2
+			case !front:
+				if kf := offset + delta - k; kf >= 0 && kf < len(forward) && forward[kf] != -1 {
+					fx := forward[kf]
+					if fx >= n-x {
+						return fx, fx - (kf - offset), true
+					}
+				}
+			}
+		}
+	}
+
+	return 0, 0, false
+}
+
+// splitLines splits text into lines, ignoring the trailing newline.
+func splitLines(text string) []string {
+	if text == "" {
+		return nil
+	}
+
+	return strings.Split(strings.TrimSuffix(text, "\n"), "\n")
+}
@@ -0,0 +1,132 @@
+package generate
+
+import (
+	"fmt"
+	"math/rand/v2"
+	"strings"
+	"testing"
+)
+
+// checkDiff verifies that ops turn oldLines into newLines, with consistent line indexes,
+// keeping the given number of unchanged lines.
+func checkDiff(t *testing.T, oldLines, newLines []string, ops []diffOp, wantCommon int) {
+	t.Helper()
+
+	var gotOld, gotNew []string
+
+	common := 0
+
+	for _, op := range ops {
+		if op.kind != '+' {
+			if op.oldLine != len(gotOld) {
+				t.Fatalf("op %c %q old line = %d, want %d", op.kind, op.text, op.oldLine, len(gotOld))
+			}
+
+			gotOld = append(gotOld, op.text)
+		}
+
+		if op.kind != '-' {
+			if op.newLine != len(gotNew) {
+				t.Fatalf("op %c %q new line = %d, want %d", op.kind, op.text, op.newLine, len(gotNew))
+			}
+
+			gotNew = append(gotNew, op.text)
+		}
+
+		if op.kind == ' ' {
+			common++
+		}
+	}
+
+	if strings.Join(gotOld, "\n") != strings.Join(oldLines, "\n") {
+		t.Fatalf("diff old side = %q, want %q", gotOld, oldLines)
+	}
+
+	if strings.Join(gotNew, "\n") != strings.Join(newLines, "\n") {
+		t.Fatalf("diff new side = %q, want %q", gotNew, newLines)
+	}
+
+	if common != wantCommon {
+		t.Fatalf("diff unchanged lines = %d, want %d (not minimal)", common, wantCommon)
+	}
+}
+
+// lcsLength is the quadratic reference for the length of the longest common subsequence.
+func lcsLength(a, b []string) int {
+	lcs := make([][]int, len(a)+1)
+	for i := range lcs {
+		lcs[i] = make([]int, len(b)+1)
+	}
+
+	for i := len(a) - 1; i >= 0; i-- {
+		for j := len(b) - 1; j >= 0; j-- {
+			if a[i] == b[j] {
+				lcs[i][j] = lcs[i+1][j+1] + 1
+			} else {
+				lcs[i][j] = max(lcs[i+1][j], lcs[i][j+1])
+			}
+		}
+	}
+
+	return lcs[0][0]
+}
+
+func TestDiffLinesRandom(t *testing.T) {
+	rng := rand.New(rand.NewPCG(1, 2))
+
+	randomLines := func() []string {
+		lines := make([]string, rng.IntN(30))
+		for i := range lines {
+			lines[i] = string(rune('a' + rng.IntN(4)))
+		}
+
+		return lines
+	}
+
+	for i := range 500 {
+		oldLines, newLines := randomLines(), randomLines()
+
+		t.Run(fmt.Sprint(i), func(t *testing.T) {
+			checkDiff(t, oldLines, newLines, diffLines(oldLines, newLines), lcsLength(oldLines, newLines))
+		})
+	}
+}
+
+func TestDiffLinesLarge(t *testing.T) {
+	// Quadratic memory would need gigabytes here
+	oldLines := make([]string, 50000)
+	for i := range oldLines {
+		oldLines[i] = fmt.Sprintf("line %d", i)
+	}
+
+	newLines := append([]string{}, oldLines...)
+	newLines[100] = "changed"
+	newLines[40000] = "changed"
+
+	checkDiff(t, oldLines, newLines, diffLines(oldLines, newLines), len(oldLines)-2)
+}
+
+func TestUnifiedDiff(t *testing.T) {
+	oldText := "a\nb\nc\nd\ne\nf\ng\nh\ni\nj\n"
+	newText := "a\nb\nc\nd\nE\nf\ng\nh\ni\nj\nk\n"
+
+	want := `--- file.ts (existing)
++++ file.ts (generated)
+@@ -2,9 +2,10 @@
+ b
+ c
+ d
+-e
++E
+ f
+ g
+ h
+ i
+ j
++k
+`
+
+	if got := unifiedDiff("file.ts", oldText, newText); got != want {
+		t.Errorf("unifiedDiff() =\n%s\nwant\n%s", got, want)
+	}
+}
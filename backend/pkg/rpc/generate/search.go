@@ -0,0 +1,124 @@
+package generate
+
+// This file (search.go) builds a lightweight search index over the API documentation,
+// so the documentation website does not have to scan all methods, events and types.
+
+import (
+	"slices"
+	"strings"
+	"unicode"
+)
+
+// SearchEntry is a single searchable item of the API documentation.
+type SearchEntry struct {
+	Kind   string   `json:"kind"`             // "method", "event", "type" or "field"
+	Name   string   `json:"name"`             // Method/event/type/field name
+	Parent string   `json:"parent,omitempty"` // Owning type name (fields only)
+	Tokens []string `json:"tokens"`           // Lowercased, deduplicated and sorted search tokens
+}
+
+// SearchIndex is the search index written next to the API documentation.
+type SearchIndex struct {
+	Entries []SearchEntry `json:"entries"` // Entries sorted by kind, parent and name
+}
+
+// NewSearchIndex builds a deterministic search index from the API documentation.
+func NewSearchIndex(d *Docs) SearchIndex {
+	entries := make([]SearchEntry, 0, len(d.Methods)+len(d.Events)+len(d.Types))
+
+	for name, method := range d.Methods {
+		entries = append(entries, newSearchEntry("method", name, "", name, method.Title, method.Description, method.Group, strings.Join(method.Tags, " ")))
+	}
+
+	for name, event := range d.Events {
+		entries = append(entries, newSearchEntry("event", name, "", name, event.Title, event.Description, event.Group, strings.Join(event.Tags, " ")))
+	}
+
+	for name, typeDocs := range d.Types {
+		entries = append(entries, newSearchEntry("type", name, "", name, typeDocs.Description))
+
+		for _, field := range typeDocs.Fields {
+			entries = append(entries, newSearchEntry("field", field.Name, name, field.Name, field.Description))
+		}
+	}
+
+	slices.SortFunc(entries, func(a, b SearchEntry) int {
+		if c := strings.Compare(a.Kind, b.Kind); c != 0 {
+			return c
+		}
+
+		if c := strings.Compare(a.Parent, b.Parent); c != 0 {
+			return c
+		}
+
+		return strings.Compare(a.Name, b.Name)
+	})
+
+	return SearchIndex{Entries: entries}
+}
+
+// newSearchEntry creates a search entry, tokenizing all the given texts.
+func newSearchEntry(kind, name, parent string, texts ...string) SearchEntry {
+	tokens := make(map[string]struct{})
+	for _, text := range texts {
+		for _, token := range tokenize(text) {
+			tokens[token] = struct{}{}
+		}
+	}
+
+	tokenList := make([]string, 0, len(tokens))
+	for token := range tokens {
+		tokenList = append(tokenList, token)
+	}
+
+	slices.Sort(tokenList)
+
+	return SearchEntry{Kind: kind, Name: name, Parent: parent, Tokens: tokenList}
+}
+
+// tokenize splits a text into lowercased words.
+// Words are separated by any non letter/digit character and on camelCase boundaries,
+// the full identifier is kept as a token too (e.g. "userID" -> "userid", "user", "id").
+func tokenize(text string) []string {
+	var tokens []string
+
+	words := strings.FieldsFunc(text, func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+
+	for _, word := range words {
+		tokens = append(tokens, strings.ToLower(word))
+
+		parts := splitCamelCase(word)
+		if len(parts) > 1 {
+			for _, part := range parts {
+				tokens = append(tokens, strings.ToLower(part))
+			}
+		}
+	}
+
+	return tokens
+}
+
+// splitCamelCase splits an identifier on lower-to-upper case boundaries,
+// keeping acronyms together (e.g. "DataCreatedEvent" -> "Data", "Created", "Event", "userID" -> "user", "ID").
+func splitCamelCase(word string) []string {
+	runes := []rune(word)
+
+	var (
+		parts []string
+		start int
+	)
+
+	for i := 1; i < len(runes); i++ {
+		lowerToUpper := unicode.IsLower(runes[i-1]) && unicode.IsUpper(runes[i])
+		acronymEnd := i+1 < len(runes) && unicode.IsUpper(runes[i-1]) && unicode.IsUpper(runes[i]) && unicode.IsLower(runes[i+1])
+
+		if lowerToUpper || acronymEnd {
+			parts = append(parts, string(runes[start:i]))
+			start = i
+		}
+	}
+
+	return append(parts, string(runes[start:]))
+}
@@ -0,0 +1,297 @@
+package generate
+
+// This file (spec.go) builds a normalized, machine-readable signature of the API (the spec),
+// and compares two specs to detect breaking changes, so CI can gate merges on them.
+
+import (
+	"encoding/json"
+	"fmt"
+	"maps"
+	"os"
+	"slices"
+	"strings"
+)
+
+// Spec is the normalized signature of the API: the shapes of the methods, events and types,
+// without any documentation, so it only changes when the API contract does.
+type Spec struct {
+	Methods map[string]SpecMethod `json:"methods"` // Method name -> signature
+	Events  map[string]SpecEvent  `json:"events"`  // Event name -> signature
+	Types   map[string]SpecType   `json:"types"`   // Type name -> signature
+}
+
+// SpecMethod is the signature of a method.
+type SpecMethod struct {
+	Params string `json:"params"` // Params type name
+	Result string `json:"result"` // Result type name
+	HTTP   bool   `json:"http"`   // Available via HTTP
+}
+
+// SpecEvent is the signature of an event.
+type SpecEvent struct {
+	Result string `json:"result"` // Event data type name
+}
+
+// SpecType is the signature of a type.
+type SpecType struct {
+	Kind       string      `json:"kind"`                 // Type kind (e.g., "Object", "String Enum")
+	EnumValues []string    `json:"enumValues,omitempty"` // Possible values if type is an enum/union
+	Fields     []SpecField `json:"fields,omitempty"`     // Fields sorted by name
+}
+
+// SpecField is the signature of a field.
+type SpecField struct {
+	Name       string   `json:"name"`                 // Field name
+	Type       string   `json:"type"`                 // TypeScript type representation
+	Optional   bool     `json:"optional"`             // Whether field may be absent
+	Nullable   bool     `json:"nullable"`             // Whether field may be null
+	EnumValues []string `json:"enumValues,omitempty"` // Possible values if type is an enum/union
+}
+
+// NewSpec builds the spec of the API from its documentation.
+func NewSpec(d *Docs) Spec {
+	spec := Spec{
+		Methods: make(map[string]SpecMethod, len(d.Methods)),
+		Events:  make(map[string]SpecEvent, len(d.Events)),
+		Types:   make(map[string]SpecType, len(d.Types)),
+	}
+
+	for name, method := range d.Methods {
+		spec.Methods[name] = SpecMethod{Params: method.ParamType.Ref, Result: method.ResultType.Ref, HTTP: method.Protocols.HTTP}
+	}
+
+	for name, event := range d.Events {
+		spec.Events[name] = SpecEvent{Result: event.ResultType.Ref}
+	}
+
+	for name, typeDocs := range d.Types {
+		fields := make([]SpecField, 0, len(typeDocs.Fields))
+		for _, field := range typeDocs.Fields {
+			fields = append(fields, SpecField{
+				Name:       field.Name,
+				Type:       field.Type,
+				Optional:   field.Optional,
+				Nullable:   field.Nullable,
+				EnumValues: field.EnumValues,
+			})
+		}
+
+		slices.SortFunc(fields, func(a, b SpecField) int { return strings.Compare(a.Name, b.Name) })
+
+		spec.Types[name] = SpecType{Kind: typeDocs.Kind, EnumValues: typeDocs.EnumValues, Fields: fields}
+	}
+
+	return spec
+}
+
+// LoadSpec reads a previously generated spec from a file.
+func LoadSpec(filePath string) (*Spec, error) {
+	data, err := os.ReadFile(filePath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read spec file: %w", err)
+	}
+
+	var s Spec
+	if err := json.Unmarshal(data, &s); err != nil {
+		return nil, fmt.Errorf("failed to parse spec file: %w", err)
+	}
+
+	return &s, nil
+}
+
+// SpecChange is a single difference between two specs.
+type SpecChange struct {
+	Breaking bool   `json:"breaking"` // Whether existing clients may break
+	Kind     string `json:"kind"`     // "method", "event", "type" or "field"
+	Name     string `json:"name"`     // Method/event/type name, or "Type.field" for fields
+	Change   string `json:"change"`   // What changed
+}
+
+// SpecReport lists the changes between two specs, breaking changes first.
+type SpecReport struct {
+	Changes []SpecChange `json:"changes"`
+}
+
+// Breaking returns true if any change is breaking.
+func (r SpecReport) Breaking() bool {
+	return slices.ContainsFunc(r.Changes, func(c SpecChange) bool { return c.Breaking })
+}
+
+// String formats the report, one change per line.
+func (r SpecReport) String() string {
+	if len(r.Changes) == 0 {
+		return "no API changes"
+	}
+
+	var b strings.Builder
+
+	for _, c := range r.Changes {
+		label := "non-breaking"
+		if c.Breaking {
+			label = "BREAKING"
+		}
+
+		fmt.Fprintf(&b, "%-12s %s %s: %s\n", label, c.Kind, c.Name, c.Change)
+	}
+
+	return strings.TrimSuffix(b.String(), "\n")
+}
+
+// CompareSpecs classifies the changes from the old to the new spec.
+// Removed methods, events, types and fields are breaking, as are added required fields, changed
+// signatures and changed enum values. Added methods, events, types and optional fields are not.
+// Since a type may be used both in params and results, changes to existing fields are conservatively breaking.
+// For the same reason added enum values are breaking, clients may not handle a new value in a result.
+func CompareSpecs(oldSpec, newSpec *Spec) SpecReport {
+	var changes []SpecChange
+
+	add := func(breaking bool, kind, name, format string, args ...any) {
+		changes = append(changes, SpecChange{Breaking: breaking, Kind: kind, Name: name, Change: fmt.Sprintf(format, args...)})
+	}
+
+	for _, name := range sortedUnion(oldSpec.Methods, newSpec.Methods) {
+		oldMethod, inOld := oldSpec.Methods[name]
+		newMethod, inNew := newSpec.Methods[name]
+
+		switch {
+		case !inNew:
+			add(true, "method", name, "removed")
+		case !inOld:
+			add(false, "method", name, "added")
+		default:
+			if oldMethod.Params != newMethod.Params {
+				add(true, "method", name, "params type changed from %s to %s", oldMethod.Params, newMethod.Params)
+			}
+
+			if oldMethod.Result != newMethod.Result {
+				add(true, "method", name, "result type changed from %s to %s", oldMethod.Result, newMethod.Result)
+			}
+
+			if oldMethod.HTTP != newMethod.HTTP {
+				add(oldMethod.HTTP, "method", name, "http availability changed from %t to %t", oldMethod.HTTP, newMethod.HTTP)
+			}
+		}
+	}
+
+	for _, name := range sortedUnion(oldSpec.Events, newSpec.Events) {
+		oldEvent, inOld := oldSpec.Events[name]
+		newEvent, inNew := newSpec.Events[name]
+
+		switch {
+		case !inNew:
+			add(true, "event", name, "removed")
+		case !inOld:
+			add(false, "event", name, "added")
+		case oldEvent.Result != newEvent.Result:
+			add(true, "event", name, "data type changed from %s to %s", oldEvent.Result, newEvent.Result)
+		}
+	}
+
+	for _, name := range sortedUnion(oldSpec.Types, newSpec.Types) {
+		oldType, inOld := oldSpec.Types[name]
+		newType, inNew := newSpec.Types[name]
+
+		switch {
+		case !inNew:
+			add(true, "type", name, "removed")
+		case !inOld:
+			add(false, "type", name, "added")
+		default:
+			changes = append(changes, compareTypes(name, oldType, newType)...)
+		}
+	}
+
+	// Breaking changes first, keeping the order of the comparison otherwise
+	slices.SortStableFunc(changes, func(a, b SpecChange) int {
+		switch {
+		case a.Breaking == b.Breaking:
+			return 0
+		case a.Breaking:
+			return -1
+		default:
+			return 1
+		}
+	})
+
+	return SpecReport{Changes: changes}
+}
+
+// compareTypes classifies the changes between two versions of a type.
+func compareTypes(name string, oldType, newType SpecType) []SpecChange {
+	var changes []SpecChange
+
+	if oldType.Kind != newType.Kind {
+		changes = append(changes, SpecChange{Breaking: true, Kind: "type", Name: name, Change: fmt.Sprintf("kind changed from %s to %s", oldType.Kind, newType.Kind)})
+	}
+
+	changes = append(changes, compareEnumValues("type", name, oldType.EnumValues, newType.EnumValues)...)
+
+	oldFields := make(map[string]SpecField, len(oldType.Fields))
+	for _, field := range oldType.Fields {
+		oldFields[field.Name] = field
+	}
+
+	newFields := make(map[string]SpecField, len(newType.Fields))
+	for _, field := range newType.Fields {
+		newFields[field.Name] = field
+	}
+
+	for _, fieldName := range sortedUnion(oldFields, newFields) {
+		oldField, inOld := oldFields[fieldName]
+		newField, inNew := newFields[fieldName]
+		qualified := name + "." + fieldName
+
+		switch {
+		case !inNew:
+			changes = append(changes, SpecChange{Breaking: true, Kind: "field", Name: qualified, Change: "removed"})
+		case !inOld && newField.Optional:
+			changes = append(changes, SpecChange{Breaking: false, Kind: "field", Name: qualified, Change: "added as optional"})
+		case !inOld:
+			changes = append(changes, SpecChange{Breaking: true, Kind: "field", Name: qualified, Change: "added as required"})
+		default:
+			if oldField.Type != newField.Type {
+				changes = append(changes, SpecChange{Breaking: true, Kind: "field", Name: qualified, Change: fmt.Sprintf("type changed from %q to %q", oldField.Type, newField.Type)})
+			}
+
+			if oldField.Optional != newField.Optional {
+				changes = append(changes, SpecChange{Breaking: true, Kind: "field", Name: qualified, Change: fmt.Sprintf("optional changed from %t to %t", oldField.Optional, newField.Optional)})
+			}
+
+			if oldField.Nullable != newField.Nullable {
+				changes = append(changes, SpecChange{Breaking: true, Kind: "field", Name: qualified, Change: fmt.Sprintf("nullable changed from %t to %t", oldField.Nullable, newField.Nullable)})
+			}
+
+			changes = append(changes, compareEnumValues("field", qualified, oldField.EnumValues, newField.EnumValues)...)
+		}
+	}
+
+	return changes
+}
+
+// compareEnumValues reports removed and added enum values, both are breaking (see [CompareSpecs]).
+func compareEnumValues(kind, name string, oldValues, newValues []string) []SpecChange {
+	var changes []SpecChange
+
+	for _, value := range oldValues {
+		if !slices.Contains(newValues, value) {
+			changes = append(changes, SpecChange{Breaking: true, Kind: kind, Name: name, Change: fmt.Sprintf("enum value %q removed", value)})
+		}
+	}
+
+	for _, value := range newValues {
+		if !slices.Contains(oldValues, value) {
+			changes = append(changes, SpecChange{Breaking: true, Kind: kind, Name: name, Change: fmt.Sprintf("enum value %q added", value)})
+		}
+	}
+
+	return changes
+}
+
+// sortedUnion returns the keys of both maps, sorted and deduplicated.
+func sortedUnion[V any](a, b map[string]V) []string {
+	keys := slices.Collect(maps.Keys(a))
+	keys = slices.AppendSeq(keys, maps.Keys(b))
+	slices.Sort(keys)
+
+	return slices.Compact(keys)
+}
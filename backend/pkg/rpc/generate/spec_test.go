@@ -0,0 +1,142 @@
+package generate
+
+import (
+	"slices"
+	"testing"
+)
+
+// newTestSpec returns a spec with a "user.get" method, a "user.created" event and their types.
+func newTestSpec() *Spec {
+	return &Spec{
+		Methods: map[string]SpecMethod{
+			"user.get": {Params: "UserGetParams", Result: "User", HTTP: true},
+		},
+		Events: map[string]SpecEvent{
+			"user.created": {Result: "User"},
+		},
+		Types: map[string]SpecType{
+			"UserGetParams": {Kind: "Object", Fields: []SpecField{{Name: "id", Type: "string"}}},
+			"User": {Kind: "Object", Fields: []SpecField{
+				{Name: "id", Type: "string"},
+				{Name: "name", Type: "string", Optional: true},
+				{Name: "role", Type: "Role", EnumValues: []string{"admin", "user"}},
+			}},
+			"Role": {Kind: "String Enum", EnumValues: []string{"admin", "user"}},
+		},
+	}
+}
+
+func TestCompareSpecs(t *testing.T) {
+	tests := []struct {
+		name   string
+		change func(s *Spec)
+		want   SpecChange
+	}{
+		{name: "method removed", change: func(s *Spec) {
+			delete(s.Methods, "user.get")
+		}, want: SpecChange{Breaking: true, Kind: "method", Name: "user.get", Change: "removed"}},
+		{name: "method added", change: func(s *Spec) {
+			s.Methods["user.list"] = SpecMethod{Params: "UserGetParams", Result: "User"}
+		}, want: SpecChange{Breaking: false, Kind: "method", Name: "user.list", Change: "added"}},
+		{name: "method params changed", change: func(s *Spec) {
+			s.Methods["user.get"] = SpecMethod{Params: "Role", Result: "User", HTTP: true}
+		}, want: SpecChange{Breaking: true, Kind: "method", Name: "user.get", Change: "params type changed from UserGetParams to Role"}},
+		{name: "method result changed", change: func(s *Spec) {
+			s.Methods["user.get"] = SpecMethod{Params: "UserGetParams", Result: "Role", HTTP: true}
+		}, want: SpecChange{Breaking: true, Kind: "method", Name: "user.get", Change: "result type changed from User to Role"}},
+		{name: "method no longer via http", change: func(s *Spec) {
+			s.Methods["user.get"] = SpecMethod{Params: "UserGetParams", Result: "User"}
+		}, want: SpecChange{Breaking: true, Kind: "method", Name: "user.get", Change: "http availability changed from true to false"}},
+		{name: "event removed", change: func(s *Spec) {
+			delete(s.Events, "user.created")
+		}, want: SpecChange{Breaking: true, Kind: "event", Name: "user.created", Change: "removed"}},
+		{name: "event added", change: func(s *Spec) {
+			s.Events["user.deleted"] = SpecEvent{Result: "User"}
+		}, want: SpecChange{Breaking: false, Kind: "event", Name: "user.deleted", Change: "added"}},
+		{name: "event data changed", change: func(s *Spec) {
+			s.Events["user.created"] = SpecEvent{Result: "Role"}
+		}, want: SpecChange{Breaking: true, Kind: "event", Name: "user.created", Change: "data type changed from User to Role"}},
+		{name: "type removed", change: func(s *Spec) {
+			delete(s.Types, "Role")
+		}, want: SpecChange{Breaking: true, Kind: "type", Name: "Role", Change: "removed"}},
+		{name: "type added", change: func(s *Spec) {
+			s.Types["Team"] = SpecType{Kind: "Object"}
+		}, want: SpecChange{Breaking: false, Kind: "type", Name: "Team", Change: "added"}},
+		{name: "type kind changed", change: func(s *Spec) {
+			s.Types["Role"] = SpecType{Kind: "Number Enum", EnumValues: []string{"admin", "user"}}
+		}, want: SpecChange{Breaking: true, Kind: "type", Name: "Role", Change: "kind changed from String Enum to Number Enum"}},
+		{name: "type enum value removed", change: func(s *Spec) {
+			s.Types["Role"] = SpecType{Kind: "String Enum", EnumValues: []string{"admin"}}
+		}, want: SpecChange{Breaking: true, Kind: "type", Name: "Role", Change: `enum value "user" removed`}},
+		{name: "type enum value added", change: func(s *Spec) {
+			s.Types["Role"] = SpecType{Kind: "String Enum", EnumValues: []string{"admin", "user", "guest"}}
+		}, want: SpecChange{Breaking: true, Kind: "type", Name: "Role", Change: `enum value "guest" added`}},
+		{name: "field removed", change: func(s *Spec) {
+			s.Types["UserGetParams"] = SpecType{Kind: "Object"}
+		}, want: SpecChange{Breaking: true, Kind: "field", Name: "UserGetParams.id", Change: "removed"}},
+		{name: "field added as optional", change: func(s *Spec) {
+			s.Types["UserGetParams"] = SpecType{Kind: "Object", Fields: []SpecField{{Name: "id", Type: "string"}, {Name: "fields", Type: "string[]", Optional: true}}}
+		}, want: SpecChange{Breaking: false, Kind: "field", Name: "UserGetParams.fields", Change: "added as optional"}},
+		{name: "field added as required", change: func(s *Spec) {
+			s.Types["UserGetParams"] = SpecType{Kind: "Object", Fields: []SpecField{{Name: "id", Type: "string"}, {Name: "fields", Type: "string[]"}}}
+		}, want: SpecChange{Breaking: true, Kind: "field", Name: "UserGetParams.fields", Change: "added as required"}},
+		{name: "field type changed", change: func(s *Spec) {
+			s.Types["UserGetParams"] = SpecType{Kind: "Object", Fields: []SpecField{{Name: "id", Type: "number"}}}
+		}, want: SpecChange{Breaking: true, Kind: "field", Name: "UserGetParams.id", Change: `type changed from "string" to "number"`}},
+		{name: "field optional changed", change: func(s *Spec) {
+			s.Types["UserGetParams"] = SpecType{Kind: "Object", Fields: []SpecField{{Name: "id", Type: "string", Optional: true}}}
+		}, want: SpecChange{Breaking: true, Kind: "field", Name: "UserGetParams.id", Change: "optional changed from false to true"}},
+		{name: "field nullable changed", change: func(s *Spec) {
+			s.Types["UserGetParams"] = SpecType{Kind: "Object", Fields: []SpecField{{Name: "id", Type: "string", Nullable: true}}}
+		}, want: SpecChange{Breaking: true, Kind: "field", Name: "UserGetParams.id", Change: "nullable changed from false to true"}},
+		{name: "field enum value removed", change: func(s *Spec) {
+			user := s.Types["User"]
+			user.Fields = slices.Clone(user.Fields)
+			user.Fields[2].EnumValues = []string{"admin"}
+			s.Types["User"] = user
+		}, want: SpecChange{Breaking: true, Kind: "field", Name: "User.role", Change: `enum value "user" removed`}},
+		{name: "field enum value added", change: func(s *Spec) {
+			user := s.Types["User"]
+			user.Fields = slices.Clone(user.Fields)
+			user.Fields[2].EnumValues = []string{"admin", "user", "guest"}
+			s.Types["User"] = user
+		}, want: SpecChange{Breaking: true, Kind: "field", Name: "User.role", Change: `enum value "guest" added`}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			newSpec := newTestSpec()
+			tt.change(newSpec)
+
+			report := CompareSpecs(newTestSpec(), newSpec)
+			if len(report.Changes) != 1 || report.Changes[0] != tt.want {
+				t.Fatalf("CompareSpecs() = %+v, want [%+v]", report.Changes, tt.want)
+			}
+
+			if report.Breaking() != tt.want.Breaking {
+				t.Errorf("Breaking() = %t, want %t", report.Breaking(), tt.want.Breaking)
+			}
+		})
+	}
+
+	if report := CompareSpecs(newTestSpec(), newTestSpec()); len(report.Changes) != 0 || report.String() != "no API changes" {
+		t.Errorf("CompareSpecs() of equal specs = %+v, want no changes", report.Changes)
+	}
+}
+
+func TestCompareSpecsBreakingFirst(t *testing.T) {
+	newSpec := newTestSpec()
+	newSpec.Methods["user.list"] = SpecMethod{Params: "UserGetParams", Result: "User"}
+	delete(newSpec.Events, "user.created")
+
+	report := CompareSpecs(newTestSpec(), newSpec)
+
+	var got []string
+	for _, c := range report.Changes {
+		got = append(got, c.Kind+" "+c.Name)
+	}
+
+	if want := []string{"event user.created", "method user.list"}; !slices.Equal(got, want) {
+		t.Errorf("CompareSpecs() changes = %v, want %v", got, want)
+	}
+}
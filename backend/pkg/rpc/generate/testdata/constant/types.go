@@ -0,0 +1,4 @@
+package constant
+
+// Limit is a constant, not a type.
+const Limit = 5
@@ -0,0 +1,13 @@
+package excludedref
+
+// Secret is left out of generation.
+//
+// @internal
+type Secret struct {
+	Value string `json:"value"`
+}
+
+// Public leaks the excluded type.
+type Public struct {
+	Secret Secret `json:"secret"`
+}
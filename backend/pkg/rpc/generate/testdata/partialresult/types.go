@@ -0,0 +1,11 @@
+package partialresult
+
+import "ws-json-rpc/backend/pkg/rpc"
+
+// DeleteParams - Parameters of a batch delete.
+type DeleteParams struct {
+	IDs []string `json:"ids"`
+}
+
+// DeleteResult - Result of a batch delete, listing the deleted and the failed IDs.
+type DeleteResult rpc.PartialResult[string]
@@ -0,0 +1,85 @@
+package generate
+
+// This file (tscheck.go) type-checks the generated TypeScript with the TypeScript compiler (tsc),
+// catching broken output before it reaches the frontend build.
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+const (
+	// tscTimeout bounds how long the TypeScript compiler may run.
+	tscTimeout = 2 * time.Minute
+	// localTSCPath is the compiler installed by the repository's npm dependencies.
+	localTSCPath = "node_modules/.bin/tsc"
+)
+
+// findTSC returns the tsc executable to use, preferring the explicitly configured path,
+// then the locally installed compiler, then one on PATH.
+func findTSC(configured string) (string, error) {
+	if configured != "" {
+		return configured, nil
+	}
+
+	if _, err := os.Stat(localTSCPath); err == nil {
+		return localTSCPath, nil
+	}
+
+	path, err := exec.LookPath("tsc")
+	if err != nil {
+		return "", errors.New("tsc not found, install the npm dependencies or set the tsc path")
+	}
+
+	return path, nil
+}
+
+// validateTypescript type-checks the given TypeScript source, returning the compiler errors if it does not compile.
+// The source is checked from a temporary file, so it works before (or without) writing the output file.
+func validateTypescript(l *slog.Logger, tscPath, source string) error {
+	tsc, err := findTSC(tscPath)
+	if err != nil {
+		return err
+	}
+
+	dir, err := os.MkdirTemp("", "ws-json-rpc-tscheck-")
+	if err != nil {
+		return fmt.Errorf("failed to create temporary directory: %w", err)
+	}
+
+	defer func() {
+		if err := os.RemoveAll(dir); err != nil {
+			l.Warn("failed to remove temporary directory", slog.String("dir", dir), slog.String("error", err.Error()))
+		}
+	}()
+
+	file := filepath.Join(dir, "generated.ts")
+	if err := os.WriteFile(file, []byte(source), 0600); err != nil {
+		return fmt.Errorf("failed to write temporary TypeScript file: %w", err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), tscTimeout)
+	defer cancel()
+
+	l.Debug("Type-checking generated TypeScript", slog.String("tsc", tsc))
+
+	//nolint:gosec // tsc path comes from the generator options or the local install
+	cmd := exec.CommandContext(ctx, tsc, "--noEmit", "--strict", "--skipLibCheck", "--target", "es2022", file)
+
+	output, err := cmd.CombinedOutput()
+	if err != nil {
+		// Report errors relative to the generated file name rather than the temporary path
+		diagnostics := strings.TrimSpace(strings.ReplaceAll(string(output), file, "generated.ts"))
+
+		return fmt.Errorf("generated TypeScript does not compile: %w\n%s", err, diagnostics)
+	}
+
+	return nil
+}
@@ -0,0 +1,173 @@
+package generate
+
+// This file (union.go) generates discriminated unions for Go interfaces annotated with "@union <field>",
+// which guts otherwise leaves out, as the union of the types implementing them.
+
+import (
+	"errors"
+	"fmt"
+	"go/types"
+	"maps"
+	"path"
+	"path/filepath"
+	"slices"
+
+	"github.com/coder/guts"
+	"github.com/coder/guts/bindings"
+)
+
+// unionType is a Go interface annotated with "@union <field>", with its variants.
+type unionType struct {
+	obj      *types.TypeName
+	field    string   // JSON name of the discriminator field
+	variants []string // Names of the types implementing the interface, sorted
+}
+
+// discriminatedUnions finds the interfaces annotated with "@union <field>" and the types implementing them,
+// with a value or a pointer receiver. Excluded types are neither unions nor variants.
+// Returns an error for unions without variants.
+func discriminatedUnions(goParser *guts.GoParser, excluded map[string]bool) ([]unionType, error) {
+	var (
+		unions     []unionType
+		candidates []*types.TypeName
+		errs       []error
+	)
+
+	pkgPaths := slices.Sorted(maps.Keys(goParser.Pkgs))
+	for _, pkgPath := range pkgPaths {
+		pkg := goParser.Pkgs[pkgPath]
+		if pkg.Types == nil {
+			continue
+		}
+
+		docs := typeDocComments(pkg.Syntax)
+
+		scope := pkg.Types.Scope()
+		for _, name := range scope.Names() {
+			obj, ok := scope.Lookup(name).(*types.TypeName)
+			if !ok || excluded[name] {
+				continue
+			}
+
+			if _, ok := obj.Type().Underlying().(*types.Interface); !ok {
+				candidates = append(candidates, obj)
+
+				continue
+			}
+
+			if _, field, ok := cutAnnotationValue(docs[name], "union"); ok {
+				unions = append(unions, unionType{obj: obj, field: field})
+			}
+		}
+	}
+
+	for i, union := range unions {
+		if union.field == "" {
+			errs = append(errs, fmt.Errorf("union %s has no discriminator, annotate it with @union <field>", union.obj.Name()))
+
+			continue
+		}
+
+		intf, _ := union.obj.Type().Underlying().(*types.Interface)
+
+		for _, candidate := range candidates {
+			if types.Implements(candidate.Type(), intf) || types.Implements(types.NewPointer(candidate.Type()), intf) {
+				unions[i].variants = append(unions[i].variants, candidate.Name())
+			}
+		}
+
+		if len(unions[i].variants) == 0 {
+			errs = append(errs, fmt.Errorf("union %s has no variants, no type implements it", union.obj.Name()))
+		}
+
+		slices.Sort(unions[i].variants)
+	}
+
+	return unions, errors.Join(errs...)
+}
+
+// addDiscriminatedUnions adds a type alias for every union, like `type Shape = Circle | Square`.
+// Every variant must be a struct with the discriminator field typed as a literal (see [applyConstAnnotations]),
+// and no two variants of a union may share a discriminator value, so TypeScript can narrow on it.
+func addDiscriminatedUnions(ts *guts.Typescript, goParser *guts.GoParser, unions []unionType) error {
+	var errs []error
+
+	for _, union := range unions {
+		members := make([]bindings.ExpressionType, 0, len(union.variants))
+		values := make(map[any]string)
+
+		for _, variant := range union.variants {
+			value, err := discriminatorValue(ts, variant, union.field)
+			if err != nil {
+				errs = append(errs, fmt.Errorf("invalid variant %s of union %s: %w", variant, union.obj.Name(), err))
+
+				continue
+			}
+
+			if other, ok := values[value]; ok {
+				errs = append(errs, fmt.Errorf("variants %s and %s of union %s share the discriminator %v", other, variant, union.obj.Name(), value))
+
+				continue
+			}
+
+			values[value] = variant
+
+			node, _ := ts.Node(variant)
+			members = append(members, bindings.Reference(node.(*bindings.Interface).Name))
+		}
+
+		alias := &bindings.Alias{
+			Name:       goParser.Identifier(union.obj),
+			Modifiers:  []bindings.Modifier{},
+			Type:       bindings.Union(members...),
+			Parameters: []*bindings.TypeParameter{},
+			Source:     unionSource(goParser, union.obj),
+		}
+		alias.AppendComments(goParser.CommentForObject(union.obj))
+
+		if err := ts.SetNode(union.obj.Name(), alias); err != nil {
+			errs = append(errs, fmt.Errorf("failed to add union %s: %w", union.obj.Name(), err))
+		}
+	}
+
+	return errors.Join(errs...)
+}
+
+// discriminatorValue returns the literal value of the discriminator field of a variant.
+func discriminatorValue(ts *guts.Typescript, variant, field string) (any, error) {
+	node, ok := ts.Node(variant)
+	if !ok {
+		return nil, errors.New("type not generated")
+	}
+
+	intf, ok := node.(*bindings.Interface)
+	if !ok {
+		return nil, errors.New("not a struct")
+	}
+
+	for _, prop := range intf.Fields {
+		if prop.Name != field {
+			continue
+		}
+
+		literal, ok := prop.Type.(*bindings.LiteralType)
+		if !ok {
+			return nil, fmt.Errorf("discriminator field %s is not a literal, annotate it with @const <value>", field)
+		}
+
+		return literal.Value, nil
+	}
+
+	return nil, fmt.Errorf("missing discriminator field %s", field)
+}
+
+// unionSource returns the source location of a union, for the "// From" line of its alias.
+func unionSource(goParser *guts.GoParser, obj *types.TypeName) bindings.Source {
+	pkg := goParser.Pkgs[obj.Pkg().Path()]
+	position := pkg.Fset.Position(obj.Pos())
+
+	return bindings.Source{
+		File:     path.Join(obj.Pkg().Name(), filepath.Base(position.Filename)),
+		Position: position,
+	}
+}
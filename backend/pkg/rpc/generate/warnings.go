@@ -0,0 +1,59 @@
+package generate
+
+// This file (warnings.go) collects the warnings of a generation run, so tooling like CI
+// can surface them without parsing the logs.
+
+import (
+	"cmp"
+	"log/slog"
+	"slices"
+)
+
+// GenerationWarning is a problem found while generating that does not fail the generation,
+// like a type whose metadata could not be extracted. Warnings are also logged.
+type GenerationWarning struct {
+	Type    string `json:"type,omitempty"`  // Name of the type the warning is about, if any
+	Field   string `json:"field,omitempty"` // Name of the field of the type, if any
+	Message string `json:"message"`         // What went wrong
+}
+
+// warningCollector logs warnings and keeps them for [GeneratorImpl.Warnings].
+type warningCollector struct {
+	l        *slog.Logger
+	warnings []GenerationWarning
+}
+
+// warn logs a warning and records it. The error, if any, is appended to the message.
+func (c *warningCollector) warn(typeName, field, message string, err error) {
+	attrs := []any{}
+	if typeName != "" {
+		attrs = append(attrs, slog.String("type", typeName))
+	}
+
+	if field != "" {
+		attrs = append(attrs, slog.String("field", field))
+	}
+
+	warning := GenerationWarning{Type: typeName, Field: field, Message: message}
+
+	if err != nil {
+		attrs = append(attrs, slog.String("error", err.Error()))
+		warning.Message += ": " + err.Error()
+	}
+
+	c.l.Warn(message, attrs...)
+	c.warnings = append(c.warnings, warning)
+}
+
+// Warnings returns the warnings of the generation so far, of both the docs and the TypeScript AST,
+// sorted by type, field and message. Types are inspected more than once, so duplicates are dropped.
+func (g *GeneratorImpl) Warnings() []GenerationWarning {
+	warnings := slices.Concat(g.guts.warnings.warnings, g.warnings.warnings)
+
+	slices.SortFunc(warnings, func(a, b GenerationWarning) int {
+		return cmp.Or(cmp.Compare(a.Type, b.Type), cmp.Compare(a.Field, b.Field), cmp.Compare(a.Message, b.Message))
+	})
+
+	// Never nil, so the warnings file holds an empty list rather than null
+	return append(make([]GenerationWarning, 0, len(warnings)), slices.Compact(warnings)...)
+}
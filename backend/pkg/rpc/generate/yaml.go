@@ -0,0 +1,188 @@
+package generate
+
+// This file (yaml.go) renders JSON examples as YAML for the docs, keeping the key order of the JSON,
+// so the output is deterministic without depending on a YAML library.
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// yamlPlainString matches strings that can be written without quotes, and are not read back as another type.
+var yamlPlainString = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_ ./-]*$`)
+
+// yamlReservedWords are plain strings YAML reads back as booleans or null.
+var yamlReservedWords = []string{"true", "false", "yes", "no", "on", "off", "y", "n", "null"}
+
+// yamlEntry is a key-value pair of an object, kept in the order of the JSON.
+type yamlEntry struct {
+	key   string
+	value any
+}
+
+// jsonToYAML converts a JSON document to YAML, keeping the order of object keys.
+func jsonToYAML(data []byte) (string, error) {
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+
+	value, err := decodeOrdered(dec)
+	if err != nil {
+		return "", fmt.Errorf("failed to decode json: %w", err)
+	}
+
+	var b strings.Builder
+
+	writeYAML(&b, value, 0)
+
+	return strings.TrimSuffix(b.String(), "\n"), nil
+}
+
+// decodeOrdered decodes the next JSON value, as []yamlEntry for objects and []any for arrays.
+func decodeOrdered(dec *json.Decoder) (any, error) {
+	tok, err := dec.Token()
+	if err != nil {
+		return nil, err
+	}
+
+	delim, ok := tok.(json.Delim)
+	if !ok {
+		return tok, nil
+	}
+
+	switch delim {
+	case '{':
+		entries := []yamlEntry{}
+
+		for dec.More() {
+			keyTok, err := dec.Token()
+			if err != nil {
+				return nil, err
+			}
+
+			key, ok := keyTok.(string)
+			if !ok {
+				return nil, errors.New("object key is not a string")
+			}
+
+			value, err := decodeOrdered(dec)
+			if err != nil {
+				return nil, err
+			}
+
+			entries = append(entries, yamlEntry{key: key, value: value})
+		}
+
+		_, err = dec.Token() // Closing brace
+
+		return entries, err
+	case '[':
+		items := []any{}
+
+		for dec.More() {
+			value, err := decodeOrdered(dec)
+			if err != nil {
+				return nil, err
+			}
+
+			items = append(items, value)
+		}
+
+		_, err = dec.Token() // Closing bracket
+
+		return items, err
+	default:
+		return nil, fmt.Errorf("unexpected delimiter %q", delim)
+	}
+}
+
+// writeYAML writes a decoded value as a YAML block at the given indentation.
+func writeYAML(b *strings.Builder, value any, indent int) {
+	pad := strings.Repeat(" ", indent)
+
+	switch v := value.(type) {
+	case []yamlEntry:
+		if len(v) == 0 {
+			b.WriteString(pad + "{}\n")
+
+			return
+		}
+
+		for _, entry := range v {
+			b.WriteString(pad + yamlScalar(entry.key) + ":")
+
+			if isYAMLBlock(entry.value) {
+				b.WriteString("\n")
+				writeYAML(b, entry.value, indent+2)
+
+				continue
+			}
+
+			b.WriteString(" " + yamlScalar(entry.value) + "\n")
+		}
+	case []any:
+		if len(v) == 0 {
+			b.WriteString(pad + "[]\n")
+
+			return
+		}
+
+		for _, item := range v {
+			if !isYAMLBlock(item) {
+				b.WriteString(pad + "- " + yamlScalar(item) + "\n")
+
+				continue
+			}
+
+			// Nested blocks start on the dash line, indented under it
+			var nested strings.Builder
+
+			writeYAML(&nested, item, indent+2)
+			b.WriteString(pad + "- " + strings.TrimPrefix(nested.String(), pad+"  "))
+		}
+	default:
+		b.WriteString(pad + yamlScalar(v) + "\n")
+	}
+}
+
+// isYAMLBlock returns true for non-empty objects and arrays, which are written as blocks.
+func isYAMLBlock(value any) bool {
+	switch v := value.(type) {
+	case []yamlEntry:
+		return len(v) > 0
+	case []any:
+		return len(v) > 0
+	default:
+		return false
+	}
+}
+
+// yamlScalar formats a scalar, or an empty object or array, as an inline YAML value.
+func yamlScalar(value any) string {
+	switch v := value.(type) {
+	case nil:
+		return "null"
+	case bool:
+		return strconv.FormatBool(v)
+	case json.Number:
+		return v.String()
+	case string:
+		if yamlPlainString.MatchString(v) && !strings.HasSuffix(v, " ") && !slices.Contains(yamlReservedWords, strings.ToLower(v)) {
+			return v
+		}
+
+		// Go escapes are all valid in YAML double-quoted strings
+		return strconv.Quote(v)
+	case []yamlEntry:
+		return "{}"
+	case []any:
+		return "[]"
+	default:
+		return fmt.Sprint(v)
+	}
+}
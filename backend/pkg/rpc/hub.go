@@ -2,15 +2,22 @@ package rpc
 
 import (
 	"context"
+	"crypto/tls"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"log/slog"
+	"net"
+	"net/http"
+	"net/netip"
+	"reflect"
+	"slices"
+	"strings"
 	"sync"
+	"sync/atomic"
 	"time"
 	"ws-json-rpc/backend/pkg/rpc/generate"
 	"ws-json-rpc/backend/pkg/utils"
-
-	"github.com/google/uuid"
 )
 
 const (
@@ -19,6 +26,14 @@ const (
 	MAX_RESPONSE_TIMEOUT         = 30 * time.Second
 	MAX_SEND_CHANNEL_TIMEOUT     = 5 * time.Second
 	MAX_MESSAGE_SIZE             = 1024 * 1024 // 1 MB
+	DEFAULT_EVENT_BATCH_SIZE     = 100
+)
+
+// Reserved methods of the application-level heartbeat, handled by the hub itself.
+// Method names starting with "$" are reserved and cannot be registered.
+const (
+	HEARTBEAT_PING_METHOD = "$ping"
+	HEARTBEAT_PONG_METHOD = "$pong"
 )
 
 const (
@@ -27,12 +42,15 @@ const (
 	ErrCodeNotFound      = -32601 // The method does not exist / is not available.
 	ErrCodeInvalidParams = -32602 // Invalid method parameter(s).
 	ErrCodeInternal      = -32603 // Internal JSON-RPC error.
+
+	ErrCodeServerNotInitialized = -32002 // The handshake method must be called before any other method on the connection.
+	ErrCodeForbidden            = -32003 // The caller is not allowed to call the method, see [Hub.WithAuthorizer].
 )
 
 // RPCRequest represents an object from the client.
 type RPCRequest struct {
 	Version string          `json:"jsonrpc"`
-	ID      uuid.UUID       `json:"id"`
+	ID      RequestID       `json:"id"`
 	Method  string          `json:"method"`
 	Params  json.RawMessage `json:"params,omitempty"`
 }
@@ -50,25 +68,36 @@ func NewEvent(eventName string, data any) RPCEvent {
 
 type EventOptions struct {
 	Docs generate.EventDocs
+	// Snapshot keeps the latest published data of the event,
+	// so it can be handed to new subscribers in their subscription confirmation.
+	Snapshot bool
+	// Direct marks an event that is only sent to single clients, like the subscription confirmations
+	// of [Hub.SubscribeAndConfirm]. It is documented like any other event, but can not be subscribed to.
+	Direct bool
 }
 
 // RegisterEvent registers an event with the hub.
 func RegisterEvent[TResult any](h *Hub, eventName string, options EventOptions) {
 	var eventZero TResult
 	h.generator.AddEventType(eventName, eventZero, options.Docs)
-	h.registerEvent(eventName)
+	h.registerEvent(eventName, options, typeSignature(reflect.TypeFor[TResult]()))
 }
 
 // RPCResponse represents a response from the server.
 type RPCResponse struct {
 	Version string          `json:"jsonrpc"`
-	ID      uuid.UUID       `json:"id"`
+	ID      RequestID       `json:"id"`
 	Result  json.RawMessage `json:"result,omitempty"`
 	Error   *RPCErrorObj    `json:"error,omitempty"`
 }
 
 // NewRPCResponse creates a new JSON-RPC 2.0 response. Result is marshaled internally.
-func NewRPCResponse(id uuid.UUID, result any, err *RPCErrorObj) RPCResponse {
+func NewRPCResponse(id RequestID, result any, err *RPCErrorObj) RPCResponse {
+	// Error responses must not carry a result
+	if err != nil {
+		return RPCResponse{Version: "2.0", ID: id, Error: err}
+	}
+
 	// Marshal the result
 	data, jsonErr := utils.ToJSON(result)
 	if jsonErr != nil {
@@ -77,7 +106,7 @@ func NewRPCResponse(id uuid.UUID, result any, err *RPCErrorObj) RPCResponse {
 		return RPCResponse{Version: "2.0", ID: id, Error: &RPCErrorObj}
 	}
 
-	return RPCResponse{Version: "2.0", ID: id, Result: data, Error: err}
+	return RPCResponse{Version: "2.0", ID: id, Result: data}
 }
 
 // RPCErrorObj represents an error on a response.
@@ -87,6 +116,47 @@ type RPCErrorObj struct {
 	Data    any    `json:"data,omitempty"`
 }
 
+// ErrorDetails is the data of internal errors when [HubOptions.DevMode] is enabled.
+type ErrorDetails struct {
+	Chain []string `json:"chain"` // Messages of the error and every error it wraps, outermost first
+}
+
+// MethodNotFoundDetails is the data of method not found errors when a registered method has a similar name.
+type MethodNotFoundDetails struct {
+	Suggestion string `json:"suggestion"` // Name of the closest registered method
+}
+
+// errorChain returns the messages of err and all the errors it wraps, depth first.
+func errorChain(err error) []string {
+	var chain []string
+
+	for err != nil {
+		chain = append(chain, err.Error())
+
+		switch e := err.(type) { //nolint:errorlint // Walking the chain manually
+		case interface{ Unwrap() []error }:
+			for _, inner := range e.Unwrap() {
+				chain = append(chain, errorChain(inner)...)
+			}
+
+			return chain
+		case interface{ Unwrap() error }:
+			err = e.Unwrap()
+		default:
+			return chain
+		}
+	}
+
+	return chain
+}
+
+// Error implements the error interface, so error objects can be returned as errors.
+// Handlers and pre-dispatch functions may return an error object (also wrapped), which is sent verbatim,
+// for full control over the error payload, like structured data with a nested cause.
+func (e *RPCErrorObj) Error() string {
+	return fmt.Sprintf("rpc error %d: %s", e.Code, e.Message)
+}
+
 // HandlerFunc is a function that handles a method call.
 type HandlerFunc func(ctx context.Context, hctx *HandlerContext, params any) (any, error)
 
@@ -94,19 +164,54 @@ type HandlerFunc func(ctx context.Context, hctx *HandlerContext, params any) (an
 type TypedHandlerFunc[TParams any, TResult any] func(ctx context.Context, hctx *HandlerContext, params TParams) (TResult, error)
 
 // MiddlewareFunc is a function that wraps a HandlerFunc with additional behavior.
+// Middlewares run after the params are parsed, around the handler, so they suit handler-level
+// concerns like logging or transforming results. Use a [PreDispatchFunc] to reject requests cheaply.
 type MiddlewareFunc func(HandlerFunc) HandlerFunc
 
+// PreDispatchFunc runs before the params of a request are parsed, and rejects the request by returning an error.
+// It suits checks that do not need the typed params, like rate limits or params size limits, which then
+// skip the parse cost of rejected requests. The raw params are available with [HandlerContext.RawParams].
+// Errors are reported like handler errors: a [HandlerError] sets the code and message, others are internal errors.
+type PreDispatchFunc func(ctx context.Context, hctx *HandlerContext, req RPCRequest) error
+
 // Method represents a registered method in the hub.
 type Method struct {
 	// The actual handler function
 	handler HandlerFunc
 	// Parses the params into the appropriate type
 	parser func(json.RawMessage) (any, error)
+	// Whether the method is deprecated (from the docs)
+	deprecated bool
+	// When the deprecated method will be removed (zero if unknown)
+	sunset time.Time
+	// The canonical method name if this is an alias (empty otherwise)
+	aliasOf string
+	// Whether this is the handshake method WebSocket clients must call first
+	handshake bool
+	// JSON shapes of the params and result, see [Hub.SignatureHash]
+	signature string
+	// The group of the method (from the docs), see [Hub.MethodGroups]
+	group string
+	// Run in order before the params are parsed, global ones first
+	preDispatch []PreDispatchFunc
 }
 
 type RegisterMethodOptions struct {
 	Middlewares []MiddlewareFunc
+	// PreDispatch runs before the params are parsed, after the global ones, see [PreDispatchFunc].
+	PreDispatch []PreDispatchFunc
 	Docs        generate.MethodDocs
+	// Sunset is the date after which a deprecated method (Docs.Deprecated) will be removed.
+	// HTTP responses of deprecated methods carry a "Deprecation: true" header, and a "Sunset" header if this is set.
+	Sunset time.Time
+	// Aliases are additional names the method is reachable under, e.g. the old name of a renamed method.
+	// Aliases are always deprecated, and documented as pointing to the canonical method name.
+	Aliases []string
+	// Handshake marks the method as the handshake, like the "initialize" method of LSP. Once a handshake
+	// is registered, WebSocket clients must successfully call it before any other method, which is rejected
+	// with [ErrCodeServerNotInitialized] until then. Its result is stored as the capabilities negotiated
+	// by the client, see [WSClient.Capabilities]. HTTP requests are stateless and not subject to the handshake.
+	Handshake bool
 }
 
 // RegisterMethod registers a method with the hub.
@@ -133,6 +238,9 @@ func RegisterMethod[TParams any, TResult any](h *Hub, method string, handler Typ
 		wrapped = options.Middlewares[i](wrapped)
 	}
 
+	// Global pre-dispatch functions run first
+	preDispatch := slices.Concat(h.preDispatch, options.PreDispatch)
+
 	var (
 		reqZero  TParams
 		respZero TResult
@@ -140,10 +248,70 @@ func RegisterMethod[TParams any, TResult any](h *Hub, method string, handler Typ
 
 	h.generator.AddHandlerType(method, reqZero, respZero, options.Docs)
 
+	signature := typeSignature(reflect.TypeFor[TParams]()) + " -> " + typeSignature(reflect.TypeFor[TResult]())
+
 	h.registerHandler(method, Method{
-		handler: wrapped,
-		parser:  parser,
+		handler:     wrapped,
+		parser:      parser,
+		deprecated:  options.Docs.Deprecated,
+		sunset:      options.Sunset,
+		handshake:   options.Handshake,
+		signature:   signature,
+		group:       options.Docs.Group,
+		preDispatch: preDispatch,
 	})
+
+	for _, alias := range options.Aliases {
+		aliasDocs := options.Docs
+		aliasDocs.Deprecated = true
+		aliasDocs.AliasOf = method
+
+		h.generator.AddHandlerType(alias, reqZero, respZero, aliasDocs)
+
+		h.registerHandler(alias, Method{
+			handler:     wrapped,
+			parser:      parser,
+			deprecated:  true,
+			sunset:      options.Sunset,
+			aliasOf:     method,
+			handshake:   options.Handshake,
+			signature:   signature,
+			group:       options.Docs.Group,
+			preDispatch: preDispatch,
+		})
+	}
+}
+
+// MethodInfo describes a registered method.
+type MethodInfo struct {
+	Name       string // Method name
+	AliasOf    string // Canonical method name if this is an alias, empty otherwise
+	Deprecated bool   // Whether the method is deprecated (aliases always are)
+}
+
+// Methods returns the registered methods, canonical methods first, each group sorted by name.
+func (h *Hub) Methods() []MethodInfo {
+	h.methodsMutex.RLock()
+	defer h.methodsMutex.RUnlock()
+
+	methods := make([]MethodInfo, 0, len(h.methods))
+	for name, method := range h.methods {
+		methods = append(methods, MethodInfo{Name: name, AliasOf: method.aliasOf, Deprecated: method.deprecated})
+	}
+
+	slices.SortFunc(methods, func(a, b MethodInfo) int {
+		if (a.AliasOf == "") != (b.AliasOf == "") {
+			if a.AliasOf == "" {
+				return -1
+			}
+
+			return 1
+		}
+
+		return strings.Compare(a.Name, b.Name)
+	})
+
+	return methods
 }
 
 // HandlerContext contains data that a handler might need.
@@ -151,6 +319,48 @@ type HandlerContext struct {
 	Logger   *slog.Logger // Logger for this specific request (has method name and request ID)
 	WSConn   *WSClient    // WSConn is the WebSocket client (nil for HTTP requests)
 	HTTPConn *HTTPClient  // HTTPConn is the HTTP client (nil for WebSocket requests)
+
+	remoteAddr string               // Client address, resolved through trusted proxies
+	tls        *tls.ConnectionState // TLS state of the connection (nil for non-TLS connections)
+	identity   string               // Identity of the caller, see [HubOptions.IdentityFunc]
+	rawParams  json.RawMessage      // Params of the request as received, set by dispatch
+	status     int                  // HTTP status of a successful response, see [HandlerContext.SetStatus]
+}
+
+// RemoteAddr returns the address of the client that made the request.
+// X-Forwarded-For is only honored when the direct peer is one of [HubOptions.TrustedProxies].
+func (hctx *HandlerContext) RemoteAddr() string {
+	return hctx.remoteAddr
+}
+
+// Identity returns the identity of the caller, as resolved by [HubOptions.IdentityFunc].
+// Empty when no IdentityFunc is set.
+func (hctx *HandlerContext) Identity() string {
+	return hctx.identity
+}
+
+// SetStatus sets the HTTP status of a successful response, like 201 Created for methods creating resources
+// or 202 Accepted for operations that complete later. Defaults to 200 OK. Error responses always use 200 OK,
+// and WebSocket responses ignore it. Statuses that are not 2xx, or that can not carry a body (204 and 205), are ignored.
+func (hctx *HandlerContext) SetStatus(code int) {
+	if code < http.StatusOK || code >= http.StatusMultipleChoices || code == http.StatusNoContent || code == http.StatusResetContent {
+		hctx.Logger.Warn("ignoring invalid success status", slog.Int("status", code))
+
+		return
+	}
+
+	hctx.status = code
+}
+
+// RawParams returns the params of the request as received, before parsing. Must not be modified.
+func (hctx *HandlerContext) RawParams() json.RawMessage {
+	return hctx.rawParams
+}
+
+// TLS returns the TLS state of the connection the request arrived on, or nil for non-TLS connections.
+// For WebSocket clients this is the state captured when the connection was upgraded.
+func (hctx *HandlerContext) TLS() *tls.ConnectionState {
+	return hctx.tls
 }
 
 type HandlerError interface {
@@ -158,6 +368,12 @@ type HandlerError interface {
 	Code() int
 }
 
+// HandlerDataError is a [HandlerError] that also sets the data of the error object sent to the client.
+type HandlerDataError interface {
+	HandlerError
+	Data() any
+}
+
 // handlerError is the default implementation of HandlerError.
 type handlerError struct {
 	code    int
@@ -177,37 +393,147 @@ func (e handlerError) Code() int {
 	return e.code
 }
 
+// HubOptions contains the configuration of a Hub.
+// Zero values are replaced with sensible defaults.
+type HubOptions struct {
+	// WriteTimeout is the maximum time a single WebSocket write may take.
+	// Clients whose writes time out are disconnected. Defaults to [MAX_RESPONSE_TIMEOUT].
+	WriteTimeout time.Duration
+	// TrustedProxies are the networks of reverse proxies whose X-Forwarded-For header is honored.
+	// When empty, the header is ignored and the address of the direct peer is used.
+	TrustedProxies []netip.Prefix
+	// ClientIDFunc returns the ID of a new WebSocket client from its upgrade request.
+	// Returning an error rejects the connection. When nil, the "clientID" query parameter is used,
+	// falling back to a generated ID. IDs colliding with a connected client get a numeric suffix.
+	ClientIDFunc func(r *http.Request) (string, error)
+	// IdentityFunc returns the identity of the caller (e.g. the owner of an API key) from its HTTP request,
+	// or the upgrade request of WebSocket clients. Returning an error rejects the request with 401 Unauthorized.
+	// The identity is passed to the authorizer (see [Hub.WithAuthorizer]). When nil, all callers have an empty identity.
+	IdentityFunc func(r *http.Request) (string, error)
+	// IdleTimeout closes WebSocket clients that have neither sent a message nor received
+	// an event within the given duration. Zero disables the idle timeout.
+	IdleTimeout time.Duration
+	// DevMode includes the chain of wrapped error messages in the data of internal errors
+	// (see [ErrorDetails]). Must not be enabled in production, as errors may leak internals.
+	// When false, internal errors only carry a generic message.
+	DevMode bool
+	// OrderedResponses sends the responses of each WebSocket client in the order its requests were received.
+	// Handlers still run concurrently, but a slow request holds back the responses of all later requests
+	// of the same client, increasing their latency. Events are not affected and may arrive in between.
+	OrderedResponses bool
+	// PingInterval sends WebSocket ping frames at the given interval, closing clients
+	// that do not answer within WriteTimeout. Zero disables transport-level pings.
+	PingInterval time.Duration
+	// HeartbeatInterval sends a "$ping" JSON-RPC notification at the given interval, closing clients that do not
+	// send a "$pong" notification back within HeartbeatTimeout. Unlike PingInterval it works through proxies that
+	// strip WebSocket control frames, and both can be enabled independently. Clients may also call "$ping"
+	// themselves, which the hub answers with the "$pong" result. Zero disables server heartbeats.
+	HeartbeatInterval time.Duration
+	// HeartbeatTimeout is how long to wait for a "$pong" after a "$ping". Defaults to HeartbeatInterval.
+	HeartbeatTimeout time.Duration
+	// EventFlushInterval enables event batching for WebSocket clients that opt in with the "batchEvents=true"
+	// query parameter. Their events are buffered for up to the given duration and sent as a single JSON array
+	// frame, in publish order. Responses are not batched and may overtake buffered events. Zero disables batching.
+	EventFlushInterval time.Duration
+	// MaxEventBatchSize flushes a batch early once it holds this many events. Defaults to [DEFAULT_EVENT_BATCH_SIZE].
+	MaxEventBatchSize int
+	// MaxClients is the maximum number of connected WebSocket clients. Upgrades beyond the limit are
+	// rejected with 503 Service Unavailable, or closed with status 1013 (try again later) if the limit
+	// was reached while upgrading. Zero means no limit.
+	MaxClients int
+	// NamePolicy is the naming convention registered event and method names (including aliases) must follow.
+	// Violations are logged and collected, see [Hub.NameErrors]. Nil accepts any name.
+	NamePolicy *NamePolicy
+	// SessionStore enables resumable sessions for WebSocket clients. The hub sends every client a [SESSION_METHOD]
+	// notification with a token when it connects, and a client reconnecting with the token in the "session"
+	// query parameter gets its subscriptions restored. Tokens are single use, a new one is issued on every connection.
+	// Nil disables sessions. Security considerations:
+	//   - Tokens are sent in the URL query, which proxies and servers commonly log. Keep the "session"
+	//     query parameter out of access logs and only serve over TLS.
+	//   - A token only resumes for the identity it was issued to (see IdentityFunc), it grants no access on its own.
+	//     Without an IdentityFunc every identity is empty, so anyone holding a token can resume its session.
+	//   - A token still held by a connected client of the same identity is handed over to the new connection,
+	//     and the old connection is closed with [CloseSessionResumed], so a session is never served twice.
+	//   - Only the subscriptions are restored, events published while the client was disconnected are not replayed.
+	SessionStore SessionStore
+	// SessionTTL is how long a session can be resumed after the client disconnects. Defaults to [DEFAULT_SESSION_TTL].
+	SessionTTL time.Duration
+}
+
+// withDefaults returns a copy of the options with zero values replaced by defaults.
+func (o HubOptions) withDefaults() HubOptions {
+	if o.WriteTimeout <= 0 {
+		o.WriteTimeout = MAX_RESPONSE_TIMEOUT
+	}
+
+	if o.HeartbeatTimeout <= 0 {
+		o.HeartbeatTimeout = o.HeartbeatInterval
+	}
+
+	if o.MaxEventBatchSize <= 0 {
+		o.MaxEventBatchSize = DEFAULT_EVENT_BATCH_SIZE
+	}
+
+	if o.SessionTTL <= 0 {
+		o.SessionTTL = DEFAULT_SESSION_TTL
+	}
+
+	return o
+}
+
 // Hub maintains active clients and broadcasts messages.
 type Hub struct {
 	logger *slog.Logger
+	opts   HubOptions
 
 	middlewares []MiddlewareFunc
+	preDispatch []PreDispatchFunc
 
 	clientCount      int
 	clientCountMutex sync.RWMutex
 
-	clients      map[*WSClient]struct{}
-	clientsMutex sync.RWMutex
+	clients        map[*WSClient]struct{}
+	clientIDs      map[string]*WSClient
+	sessionClients map[string]*WSClient // Connected clients by session token, guarded by clientsMutex
+	clientsMutex   sync.RWMutex
 
 	methods      map[string]Method
+	handshake    bool // Whether a handshake method is registered, guarded by methodsMutex
 	methodsMutex sync.RWMutex
 
+	nameErrors      []error
+	nameErrorsMutex sync.Mutex
+
 	subscriptions      map[string]map[*WSClient]struct{}
+	clientEvents       map[*WSClient]map[string]struct{} // Per-client index of subscribed events, guarded by subscriptionsMutex
+	snapshots          map[string]json.RawMessage        // Latest data of events registered with a snapshot, guarded by subscriptionsMutex
+	directEvents       map[string]struct{}               // Events that can not be subscribed to, guarded by subscriptionsMutex
+	eventSignatures    map[string]string                 // JSON shape of the data of each event, guarded by subscriptionsMutex
 	subscriptionsMutex sync.RWMutex
 
 	register   chan *WSClient
 	unregister chan *WSClient
 	eventChan  chan RPCEvent
 
+	eventQueueFull      atomic.Uint64                          // Number of events published while eventChan was full
+	eventQueueSaturated atomic.Bool                            // Set once eventChan is full, until it drained below half its capacity
+	eventQueueFullFunc  atomic.Pointer[func(event RPCEvent)]   // Set by [Hub.OnEventQueueFull]
+	connectFunc         atomic.Pointer[func(client *WSClient)] // Set by [Hub.OnConnect]
+	disconnectFunc      atomic.Pointer[func(client *WSClient)] // Set by [Hub.OnDisconnect]
+
+	authorizer   atomic.Pointer[AuthorizerFunc] // Set by [Hub.WithAuthorizer]
+	shuttingDown atomic.Bool                    // Set by [Hub.Shutdown], new clients are rejected
+
 	generator generate.Generator
 }
 
 // NewHub creates a new Hub instance.
-func NewHub(l *slog.Logger, g generate.Generator) *Hub {
+func NewHub(l *slog.Logger, g generate.Generator, opts HubOptions) *Hub {
 	logger := l.With(slog.String("component", "hub"))
 
 	return &Hub{
 		logger:     logger,
+		opts:       opts.withDefaults(),
 		register:   make(chan *WSClient),
 		unregister: make(chan *WSClient),
 		eventChan:  make(chan RPCEvent, 100),
@@ -215,13 +541,21 @@ func NewHub(l *slog.Logger, g generate.Generator) *Hub {
 		clientCount:      0,
 		clientCountMutex: sync.RWMutex{},
 
-		clients:      make(map[*WSClient]struct{}),
-		clientsMutex: sync.RWMutex{},
+		clients:        make(map[*WSClient]struct{}),
+		clientIDs:      make(map[string]*WSClient),
+		sessionClients: make(map[string]*WSClient),
+		clientsMutex:   sync.RWMutex{},
 
 		methods:      make(map[string]Method),
 		methodsMutex: sync.RWMutex{},
 
+		nameErrorsMutex: sync.Mutex{},
+
 		subscriptions:      make(map[string]map[*WSClient]struct{}),
+		clientEvents:       make(map[*WSClient]map[string]struct{}),
+		snapshots:          make(map[string]json.RawMessage),
+		directEvents:       make(map[string]struct{}),
+		eventSignatures:    make(map[string]string),
 		subscriptionsMutex: sync.RWMutex{},
 
 		generator: g,
@@ -233,21 +567,126 @@ func (h *Hub) GenerateDocs() error {
 }
 
 // PublishEvent sends an event to all subscribed clients.
+// It blocks until the event is queued, use [Hub.PublishEventCtx] to be able to give up.
 func (h *Hub) PublishEvent(event RPCEvent) {
+	if h.tryQueueEvent(event) {
+		return
+	}
+
 	h.eventChan <- event
 }
 
+// PublishEventCtx sends an event to all subscribed clients.
+// Returns an error if the context is cancelled before the event is queued.
+func (h *Hub) PublishEventCtx(ctx context.Context, event RPCEvent) error {
+	if h.tryQueueEvent(event) {
+		return nil
+	}
+
+	select {
+	case h.eventChan <- event:
+		return nil
+	case <-ctx.Done():
+		return fmt.Errorf("failed to queue event %q: %w", event.EventName, ctx.Err())
+	}
+}
+
+// OnEventQueueFull sets a function called when a published event finds the event queue full,
+// before the publisher starts waiting for room. It is called once as the queue fills up, and again only
+// after the queue drained below half its capacity, with the event that found it full. It runs in its own
+// goroutine, so it never delays the publisher. It should not publish events, as those would wait for room.
+// Use it to alert on backpressure, see also [HubStats].
+func (h *Hub) OnEventQueueFull(fn func(event RPCEvent)) {
+	h.eventQueueFullFunc.Store(&fn)
+}
+
+// OnConnect sets a function called whenever a WebSocket client connects, e.g. to track presence.
+// It is called once the client is fully registered, so [Hub.Stats] includes it.
+// It runs in its own goroutine, so it never blocks the hub, and it may run concurrently with the
+// [Hub.OnDisconnect] function of the same client if the client disconnects right away.
+func (h *Hub) OnConnect(fn func(client *WSClient)) {
+	h.connectFunc.Store(&fn)
+}
+
+// OnDisconnect sets a function called whenever a registered WebSocket client disconnects.
+// It is called once the client is fully unregistered, so [Hub.Stats] no longer includes it.
+// It runs in its own goroutine, so it never blocks the hub. Clients rejected because
+// [HubOptions.MaxClients] was reached were never connected and trigger neither hook.
+func (h *Hub) OnDisconnect(fn func(client *WSClient)) {
+	h.disconnectFunc.Store(&fn)
+}
+
+// tryQueueEvent queues an event without blocking. When the queue is full it records the
+// saturation and returns false. The first event finding the queue full logs a warning and calls
+// the [Hub.OnEventQueueFull] function, the queue is then reported again only once it drained below
+// half its capacity, so a saturated hub does not flood the logs or spawn a goroutine per event.
+func (h *Hub) tryQueueEvent(event RPCEvent) bool {
+	select {
+	case h.eventChan <- event:
+		if h.eventQueueSaturated.Load() && len(h.eventChan) < cap(h.eventChan)/2 && h.eventQueueSaturated.CompareAndSwap(true, false) {
+			h.logger.Info("event queue recovered", slog.Uint64("full_events", h.eventQueueFull.Load()))
+		}
+
+		return true
+	default:
+	}
+
+	h.eventQueueFull.Add(1)
+
+	if !h.eventQueueSaturated.CompareAndSwap(false, true) {
+		return false
+	}
+
+	h.logger.Warn("event queue full, publishers are waiting", slog.String("event", event.EventName), slog.Int("capacity", cap(h.eventChan)))
+
+	if fn := h.eventQueueFullFunc.Load(); fn != nil && *fn != nil {
+		go (*fn)(event)
+	}
+
+	return false
+}
+
 // Subscribe adds a client to an event subscription.
 func (h *Hub) Subscribe(client *WSClient, event string) error {
+	return h.subscribe(client, event, nil)
+}
+
+// SubscribeAndConfirm adds a client to an event subscription and queues a confirmation event to just that client.
+// The confirmation is built by confirm from the snapshot of the event, which is nil if the event was not registered
+// with [EventOptions.Snapshot] or nothing was published yet.
+// The confirmation is queued before any event published after the subscription became active.
+// Register the confirmation event with [EventOptions.Direct], so clients can not subscribe to it.
+func (h *Hub) SubscribeAndConfirm(client *WSClient, event string, confirm func(snapshot json.RawMessage) RPCEvent) error {
+	return h.subscribe(client, event, confirm)
+}
+
+func (h *Hub) subscribe(client *WSClient, event string, confirm func(snapshot json.RawMessage) RPCEvent) error {
 	h.subscriptionsMutex.Lock()
 	// Check if event is registered
 	if _, ok := h.subscriptions[event]; !ok {
+		_, direct := h.directEvents[event]
 		h.subscriptionsMutex.Unlock()
 
+		if direct {
+			return fmt.Errorf("event %s is only sent directly to clients and can not be subscribed to", event)
+		}
+
 		return fmt.Errorf("unknown event: %s", event)
 	}
 
 	h.subscriptions[event][client] = struct{}{}
+	if h.clientEvents[client] == nil {
+		h.clientEvents[client] = make(map[string]struct{})
+	}
+
+	h.clientEvents[client][event] = struct{}{}
+
+	// Queue the confirmation while holding the lock, so a concurrent broadcast
+	// either updates the snapshot first or is queued after the confirmation
+	if confirm != nil {
+		h.sendConfirmation(client, confirm(h.snapshots[event]))
+	}
+
 	h.subscriptionsMutex.Unlock()
 
 	client.logger.Info("subscribed to event", slog.String("event", event))
@@ -255,6 +694,23 @@ func (h *Hub) Subscribe(client *WSClient, event string) error {
 	return nil
 }
 
+// sendConfirmation queues a subscription confirmation event to a single client, dropping it if the client is backed up.
+func (h *Hub) sendConfirmation(client *WSClient, event RPCEvent) {
+	data, err := utils.ToJSON(event)
+	if err != nil {
+		client.logger.Error("failed to marshal subscription confirmation", slog.String("event", event.EventName), utils.ErrAttr(err))
+
+		return
+	}
+
+	select {
+	case client.eventChannel() <- data:
+		client.touch()
+	default:
+		client.logger.Warn("send channel full, dropping subscription confirmation", slog.String("event", event.EventName))
+	}
+}
+
 // Unsubscribe removes a client from an event subscription.
 func (h *Hub) Unsubscribe(client *WSClient, event string) {
 	h.subscriptionsMutex.Lock()
@@ -263,11 +719,70 @@ func (h *Hub) Unsubscribe(client *WSClient, event string) {
 		delete(subscribers, client)
 	}
 
+	if events, ok := h.clientEvents[client]; ok {
+		delete(events, event)
+
+		if len(events) == 0 {
+			delete(h.clientEvents, client)
+		}
+	}
+
 	h.subscriptionsMutex.Unlock()
 
 	client.logger.Info("unsubscribed from event", slog.String("event", event))
 }
 
+// Subscriptions returns the events a client is subscribed to, sorted by name.
+func (h *Hub) Subscriptions(client *WSClient) []string {
+	h.subscriptionsMutex.RLock()
+	defer h.subscriptionsMutex.RUnlock()
+
+	events := make([]string, 0, len(h.clientEvents[client]))
+	for event := range h.clientEvents[client] {
+		events = append(events, event)
+	}
+
+	slices.Sort(events)
+
+	return events
+}
+
+// HubStats is a snapshot of the state of a hub.
+type HubStats struct {
+	Clients    int // Number of connected clients
+	MaxClients int // Maximum number of connected WebSocket clients, zero if unlimited
+
+	EventQueueLen  int    // Number of published events waiting to be broadcast
+	EventQueueCap  int    // Capacity of the event queue, publishers wait once it is full
+	EventQueueFull uint64 // Number of events published while the event queue was full, since the hub was created
+}
+
+// Stats returns a snapshot of the state of the hub.
+func (h *Hub) Stats() HubStats {
+	h.clientCountMutex.RLock()
+	defer h.clientCountMutex.RUnlock()
+
+	return HubStats{
+		Clients:        h.clientCount,
+		MaxClients:     h.opts.MaxClients,
+		EventQueueLen:  len(h.eventChan),
+		EventQueueCap:  cap(h.eventChan),
+		EventQueueFull: h.eventQueueFull.Load(),
+	}
+}
+
+// full returns true if the hub reached [HubOptions.MaxClients].
+func (h *Hub) full() bool {
+	if h.opts.MaxClients <= 0 {
+		return false
+	}
+
+	h.clientCountMutex.RLock()
+	defer h.clientCountMutex.RUnlock()
+
+	return h.clientCount >= h.opts.MaxClients
+}
+
 // WithMiddleware adds middleware to the hub that will be applied to all registered methods.
 func (h *Hub) WithMiddleware(middlewares ...MiddlewareFunc) *Hub {
 	h.middlewares = append(h.middlewares, middlewares...)
@@ -275,6 +790,14 @@ func (h *Hub) WithMiddleware(middlewares ...MiddlewareFunc) *Hub {
 	return h
 }
 
+// WithPreDispatch adds pre-dispatch functions to the hub that will run before the params of all registered methods are parsed.
+// Like middlewares, they only apply to methods registered afterwards.
+func (h *Hub) WithPreDispatch(fns ...PreDispatchFunc) *Hub {
+	h.preDispatch = append(h.preDispatch, fns...)
+
+	return h
+}
+
 // Run starts the hub's main loop.
 func (h *Hub) Run() {
 	h.logger.Info("hub started")
@@ -282,7 +805,7 @@ func (h *Hub) Run() {
 	for {
 		select {
 		case client := <-h.register:
-			h.clientRegister(client)
+			h.clientAdmit(client)
 
 		case client := <-h.unregister:
 			h.clientUnregister(client)
@@ -293,25 +816,146 @@ func (h *Hub) Run() {
 	}
 }
 
-// registerEvent registers an event that clients can subscribe to.
-func (h *Hub) registerEvent(eventName string) {
+// registerEvent registers an event that clients can subscribe to, or a direct event (see [EventOptions.Direct]).
+// With [EventOptions.Snapshot], the latest published data of the event is kept for subscription confirmations.
+func (h *Hub) registerEvent(eventName string, options EventOptions, signature string) {
+	h.checkName("event", eventName)
+
 	h.subscriptionsMutex.Lock()
 	defer h.subscriptionsMutex.Unlock()
 
-	if _, exists := h.subscriptions[eventName]; exists {
+	_, subscribable := h.subscriptions[eventName]
+	if _, direct := h.directEvents[eventName]; subscribable || direct {
 		h.logger.Warn("event already registered", slog.String("event", eventName))
 
 		return
 	}
 
+	h.eventSignatures[eventName] = signature
+
+	if options.Direct {
+		h.directEvents[eventName] = struct{}{}
+		h.logger.Debug("direct event registered", slog.String("event", eventName))
+
+		return
+	}
+
 	h.subscriptions[eventName] = make(map[*WSClient]struct{})
+	if options.Snapshot {
+		h.snapshots[eventName] = nil
+	}
+
 	h.logger.Debug("event registered", slog.String("event", eventName))
 }
 
+// getMethod returns a registered method by name.
+func (h *Hub) getMethod(methodName string) (Method, bool) {
+	h.methodsMutex.RLock()
+	defer h.methodsMutex.RUnlock()
+
+	method, exists := h.methods[methodName]
+
+	return method, exists
+}
+
+// requiresHandshake returns true if a handshake method is registered.
+func (h *Hub) requiresHandshake() bool {
+	h.methodsMutex.RLock()
+	defer h.methodsMutex.RUnlock()
+
+	return h.handshake
+}
+
 // registerHandler registers a method handler.
 func (h *Hub) registerHandler(methodName string, handler Method) {
+	if strings.HasPrefix(methodName, "$") {
+		err := fmt.Errorf("method name %q is reserved, names starting with $ are handled by the hub", methodName)
+		h.logger.Error("method not registered", slog.String("method", methodName), utils.ErrAttr(err))
+		h.addNameError(err)
+
+		return
+	}
+
+	h.checkName("method", methodName)
+
 	h.methodsMutex.Lock()
 	h.methods[methodName] = handler
+	h.handshake = h.handshake || handler.handshake
 	h.methodsMutex.Unlock()
 	h.logger.Debug("method registered", slog.String("method", methodName))
 }
+
+// checkName records a violation of the naming policy by an event or method name.
+func (h *Hub) checkName(kind, name string) {
+	if h.opts.NamePolicy == nil {
+		return
+	}
+
+	if err := h.opts.NamePolicy.Check(name); err != nil {
+		err = fmt.Errorf("%s %w", kind, err)
+		h.logger.Error("naming policy violation", slog.String(kind, name), utils.ErrAttr(err))
+		h.addNameError(err)
+	}
+}
+
+// addNameError records an invalid event or method name, see [Hub.NameErrors].
+func (h *Hub) addNameError(err error) {
+	h.nameErrorsMutex.Lock()
+	h.nameErrors = append(h.nameErrors, err)
+	h.nameErrorsMutex.Unlock()
+}
+
+// NameErrors returns the naming policy violations of the registered events and methods joined into one error,
+// or nil if all names follow [HubOptions.NamePolicy]. Methods with reserved names, which are not registered,
+// are reported too. Call it after registration to reject nonconforming names.
+func (h *Hub) NameErrors() error {
+	h.nameErrorsMutex.Lock()
+	defer h.nameErrorsMutex.Unlock()
+
+	return errors.Join(h.nameErrors...)
+}
+
+// remoteHost returns the host of the client that made the request.
+// When the direct peer is a trusted proxy, the X-Forwarded-For chain is walked from
+// right to left and the first address that is not a trusted proxy is returned.
+func (h *Hub) remoteHost(r *http.Request) (string, error) {
+	peer, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return "", fmt.Errorf("failed to parse remote address %q: %w", r.RemoteAddr, err)
+	}
+
+	if !h.isTrustedProxy(peer) {
+		return peer, nil
+	}
+
+	forwarded := strings.Split(strings.Join(r.Header.Values("X-Forwarded-For"), ","), ",")
+	for i := len(forwarded) - 1; i >= 0; i-- {
+		addr := strings.TrimSpace(forwarded[i])
+		if addr == "" {
+			continue
+		}
+
+		if !h.isTrustedProxy(addr) {
+			return addr, nil
+		}
+	}
+
+	return peer, nil
+}
+
+// isTrustedProxy checks if the given host is part of the trusted proxy networks.
+func (h *Hub) isTrustedProxy(host string) bool {
+	addr, err := netip.ParseAddr(host)
+	if err != nil {
+		return false
+	}
+
+	addr = addr.Unmap()
+	for _, prefix := range h.opts.TrustedProxies {
+		if prefix.Contains(addr) {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,145 @@
+package rpc
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"log/slog"
+	"testing"
+	"time"
+
+	"ws-json-rpc/backend/pkg/rpc/generate"
+)
+
+type echoParams struct {
+	Message string `json:"message"`
+}
+
+type echoResult struct {
+	Message string `json:"message"`
+}
+
+// newTestHub creates a running hub with an "echo" method, a "fail" method and a "ping" event.
+func newTestHub(t *testing.T, opts HubOptions) *Hub {
+	t.Helper()
+
+	return newTestHubWithLogger(t, slog.New(slog.NewTextHandler(io.Discard, nil)), opts)
+}
+
+// newTestHubWithLogger creates a running test hub (see newTestHub) logging to l.
+func newTestHubWithLogger(t *testing.T, l *slog.Logger, opts HubOptions) *Hub {
+	t.Helper()
+
+	h := NewHub(l, &generate.MockGenerator{}, opts)
+
+	RegisterMethod(h, "echo", func(_ context.Context, _ *HandlerContext, params echoParams) (echoResult, error) {
+		return echoResult(params), nil
+	}, RegisterMethodOptions{})
+	RegisterMethod(h, "fail", func(_ context.Context, _ *HandlerContext, _ struct{}) (struct{}, error) {
+		return struct{}{}, NewHandlerError(-32042, "failed on purpose")
+	}, RegisterMethodOptions{})
+	RegisterEvent[echoResult](h, "ping", EventOptions{})
+
+	go h.Run()
+
+	return h
+}
+
+// newTestWSClient creates a WebSocket client without a connection, its messages stay queued on its send channel.
+func newTestWSClient(h *Hub) *WSClient {
+	return &WSClient{
+		hub:         h,
+		id:          "test",
+		sendChannel: make(chan []byte, MAX_QUEUED_EVENTS_PER_CLIENT),
+		logger:      h.logger,
+	}
+}
+
+func TestDirectEvent(t *testing.T) {
+	h := newTestHub(t, HubOptions{})
+	RegisterEvent[echoResult](h, "confirmed", EventOptions{Direct: true})
+
+	c := newTestClient(t, h)
+
+	if err := h.Subscribe(c.WSClient(), "confirmed"); err == nil {
+		t.Error("Subscribe() to a direct event error = nil, want rejection")
+	}
+
+	err := h.SubscribeAndConfirm(c.WSClient(), "ping", func(json.RawMessage) RPCEvent {
+		return NewEvent("confirmed", echoResult{Message: "ping"})
+	})
+	if err != nil {
+		t.Fatalf("SubscribeAndConfirm() error = %v", err)
+	}
+
+	ctx, cancel := context.WithTimeout(t.Context(), time.Second)
+	defer cancel()
+
+	event, err := c.NextEvent(ctx)
+	if err != nil {
+		t.Fatalf("NextEvent() error = %v", err)
+	}
+
+	if event.EventName != "confirmed" || string(event.Data) != `{"message":"ping"}` {
+		t.Errorf("NextEvent() = %s %s, want confirmed {\"message\":\"ping\"}", event.EventName, event.Data)
+	}
+}
+
+func TestEventQueueFullReportedOncePerSaturation(t *testing.T) {
+	// Not running, so the event queue is only drained by the test
+	h := NewHub(slog.New(slog.NewTextHandler(io.Discard, nil)), &generate.MockGenerator{}, HubOptions{})
+	RegisterEvent[echoResult](h, "ping", EventOptions{})
+
+	calls := make(chan RPCEvent, 100)
+	h.OnEventQueueFull(func(event RPCEvent) {
+		calls <- event
+	})
+
+	fill := func() {
+		for range cap(h.eventChan) + 10 {
+			h.tryQueueEvent(NewEvent("ping", nil))
+		}
+	}
+
+	expectCalls := func(want int) {
+		t.Helper()
+
+		for range want {
+			select {
+			case <-calls:
+			case <-time.After(time.Second):
+				t.Fatal("OnEventQueueFull() was not called")
+			}
+		}
+
+		select {
+		case <-calls:
+			t.Fatal("OnEventQueueFull() called again while the queue stayed full")
+		case <-time.After(50 * time.Millisecond):
+		}
+	}
+
+	fill()
+	expectCalls(1)
+
+	if got := h.Stats().EventQueueFull; got != 10 {
+		t.Errorf("Stats().EventQueueFull = %d, want 10", got)
+	}
+
+	// Draining a little is not a recovery
+	for range 5 {
+		<-h.eventChan
+	}
+
+	fill()
+	expectCalls(0)
+
+	// Drained below half, so filling up again is reported again
+	for len(h.eventChan) > 0 {
+		<-h.eventChan
+	}
+
+	h.tryQueueEvent(NewEvent("ping", nil))
+	fill()
+	expectCalls(1)
+}
@@ -0,0 +1,93 @@
+package rpc
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"github.com/google/uuid"
+)
+
+// RequestID is the id of a JSON-RPC request, which may be a JSON string, number or null.
+// It holds the raw JSON of the id, so responses echo it back verbatim.
+// The zero value is an absent id, the id of notifications, which get no response.
+type RequestID json.RawMessage
+
+// NullID is the id of responses to requests whose id could not be determined.
+var NullID = RequestID("null")
+
+var errInvalidID = errors.New("id must be a string, number or null")
+
+// NewRequestID creates a string id from a UUID.
+func NewRequestID(id uuid.UUID) RequestID {
+	return RequestID(`"` + id.String() + `"`)
+}
+
+// IsNull returns true if the id is null. An absent id is not null, see [RequestID.IsAbsent].
+func (id RequestID) IsNull() bool {
+	return bytes.Equal(id, []byte("null"))
+}
+
+// IsAbsent returns true if the request had no id, which makes it a notification.
+func (id RequestID) IsAbsent() bool {
+	return len(id) == 0
+}
+
+// String returns the id for logging: the value of string ids, the literal of number ids,
+// "null", or "" for an absent id.
+func (id RequestID) String() string {
+	if id.IsAbsent() || id.IsNull() {
+		return string(id)
+	}
+
+	var s string
+	if err := json.Unmarshal(id, &s); err == nil {
+		return s
+	}
+
+	return string(id)
+}
+
+// UUID parses the id as a UUID, as generated by the bundled clients.
+// Returns an error if the id is not a string holding a UUID.
+func (id RequestID) UUID() (uuid.UUID, error) {
+	var s string
+	if err := json.Unmarshal(id, &s); err != nil {
+		return uuid.Nil, fmt.Errorf("id %s is not a string", id.String())
+	}
+
+	parsed, err := uuid.Parse(s)
+	if err != nil {
+		return uuid.Nil, fmt.Errorf("id %q is not a UUID: %w", s, err)
+	}
+
+	return parsed, nil
+}
+
+// MarshalJSON writes the raw id, or null for an absent id.
+func (id RequestID) MarshalJSON() ([]byte, error) {
+	if len(id) == 0 {
+		return []byte("null"), nil
+	}
+
+	return id, nil
+}
+
+// UnmarshalJSON stores the raw id, rejecting values that are not a string, number or null.
+func (id *RequestID) UnmarshalJSON(data []byte) error {
+	var value any
+	if err := json.Unmarshal(data, &value); err != nil {
+		return err
+	}
+
+	switch value.(type) {
+	case string, float64, nil:
+	default:
+		return errInvalidID
+	}
+
+	*id = append((*id)[:0], data...)
+
+	return nil
+}
@@ -0,0 +1,101 @@
+package rpc
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestRequestIDUnmarshal(t *testing.T) {
+	tests := []struct {
+		name   string
+		body   string
+		absent bool
+		null   bool
+		wantID string
+	}{
+		{name: "absent", body: `{"method":"echo"}`, absent: true, wantID: "null"},
+		{name: "null", body: `{"id":null,"method":"echo"}`, null: true, wantID: "null"},
+		{name: "string", body: `{"id":"abc","method":"echo"}`, wantID: `"abc"`},
+		{name: "number", body: `{"id":42,"method":"echo"}`, wantID: "42"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var req RPCRequest
+			if err := json.Unmarshal([]byte(tt.body), &req); err != nil {
+				t.Fatalf("Unmarshal() error = %v", err)
+			}
+
+			if req.ID.IsAbsent() != tt.absent || req.ID.IsNull() != tt.null {
+				t.Errorf("IsAbsent() = %v, IsNull() = %v, want %v, %v", req.ID.IsAbsent(), req.ID.IsNull(), tt.absent, tt.null)
+			}
+
+			data, err := json.Marshal(NewRPCResponse(req.ID, nil, nil))
+			if err != nil {
+				t.Fatalf("Marshal() error = %v", err)
+			}
+
+			if want := `"id":` + tt.wantID; !strings.Contains(string(data), want) {
+				t.Errorf("response = %s, want it to contain %s", data, want)
+			}
+		})
+	}
+
+	var req RPCRequest
+	if err := json.Unmarshal([]byte(`{"id":{},"method":"echo"}`), &req); err == nil {
+		t.Error("Unmarshal() object id error = nil, want an error")
+	}
+}
+
+func TestNotification(t *testing.T) {
+	h := newTestHub(t, HubOptions{})
+
+	t.Run("http", func(t *testing.T) {
+		tests := []struct {
+			name   string
+			body   string
+			status int
+		}{
+			{name: "notification", body: `{"jsonrpc":"2.0","method":"echo","params":{"message":"hi"}}`, status: http.StatusNoContent},
+			{name: "failed notification", body: `{"jsonrpc":"2.0","method":"fail"}`, status: http.StatusNoContent},
+			{name: "null id", body: `{"jsonrpc":"2.0","id":null,"method":"echo","params":{"message":"hi"}}`, status: http.StatusOK},
+		}
+
+		for _, tt := range tests {
+			t.Run(tt.name, func(t *testing.T) {
+				rec := httptest.NewRecorder()
+				h.ServeHTTP()(rec, httptest.NewRequest(http.MethodPost, "/rpc", strings.NewReader(tt.body)))
+
+				if rec.Code != tt.status {
+					t.Errorf("status = %d, want %d", rec.Code, tt.status)
+				}
+
+				if tt.status == http.StatusNoContent && rec.Body.Len() != 0 {
+					t.Errorf("body = %s, want empty", rec.Body)
+				}
+			})
+		}
+	})
+
+	t.Run("ws", func(t *testing.T) {
+		c := newTestWSClient(h)
+
+		// The slot of a notification is released without a response
+		slot := make(chan RPCResponse, 1)
+		c.handleRequest(t.Context(), RPCRequest{Version: "2.0", Method: "fail"}, slot)
+
+		if resp, ok := <-slot; ok {
+			t.Errorf("notification response = %+v, want none", resp)
+		}
+
+		slot = make(chan RPCResponse, 1)
+		c.handleRequest(t.Context(), RPCRequest{Version: "2.0", ID: NullID, Method: "fail"}, slot)
+
+		if resp := <-slot; !resp.ID.IsNull() || resp.Error == nil {
+			t.Errorf("null id response = %+v, want an error with a null id", resp)
+		}
+	})
+}
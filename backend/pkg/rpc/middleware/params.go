@@ -0,0 +1,32 @@
+package middleware
+
+import (
+	"context"
+	"fmt"
+	"ws-json-rpc/backend/pkg/rpc"
+	"ws-json-rpc/backend/pkg/rpc/generate"
+)
+
+// MaxParamsSize rejects requests whose raw params are larger than maxBytes with [rpc.ErrCodeInvalidParams],
+// for methods that need a tighter limit than [rpc.MAX_MESSAGE_SIZE], like a search query.
+// Attach it as a method pre-dispatch function, and list [MaxParamsSizeError] in the errors of the method docs.
+// It runs before the params are parsed, so oversized params are rejected without paying the parse cost.
+func MaxParamsSize(maxBytes int) rpc.PreDispatchFunc {
+	return func(ctx context.Context, hctx *rpc.HandlerContext, req rpc.RPCRequest) error {
+		if size := len(req.Params); size > maxBytes {
+			return rpc.NewHandlerError(rpc.ErrCodeInvalidParams, fmt.Sprintf("Params too large: %d bytes, the limit is %d bytes", size, maxBytes))
+		}
+
+		return nil
+	}
+}
+
+// MaxParamsSizeError documents the error returned by [MaxParamsSize], for the errors of the method docs.
+func MaxParamsSizeError(maxBytes int) generate.ErrorDoc {
+	return generate.ErrorDoc{
+		Title:       "Params too large",
+		Description: fmt.Sprintf("The params are larger than %d bytes", maxBytes),
+		Code:        rpc.ErrCodeInvalidParams,
+		Message:     fmt.Sprintf("Params too large: %d bytes, the limit is %d bytes", maxBytes+1, maxBytes),
+	}
+}
@@ -0,0 +1,193 @@
+package middleware
+
+import (
+	"context"
+	"encoding"
+	"encoding/json"
+	"log/slog"
+	"reflect"
+	"strconv"
+	"strings"
+	"ws-json-rpc/backend/pkg/rpc"
+)
+
+// redactedValue replaces the values of sensitive fields in logged payloads.
+const redactedValue = "[REDACTED]"
+
+// PayloadLogMiddleware logs the params and result of every request at debug level.
+// Fields listed in sensitive (Go package path and type name -> JSON field names, see generate.Docs.SensitiveFields)
+// are masked, at any depth of the payload, including in values held by interface fields.
+func PayloadLogMiddleware(sensitive map[string][]string) rpc.MiddlewareFunc {
+	redactions := make(map[string]map[string]struct{}, len(sensitive))
+	for typeName, fields := range sensitive {
+		redactions[typeName] = make(map[string]struct{}, len(fields))
+		for _, field := range fields {
+			redactions[typeName][field] = struct{}{}
+		}
+	}
+
+	return func(next rpc.HandlerFunc) rpc.HandlerFunc {
+		return func(ctx context.Context, hctx *rpc.HandlerContext, params any) (any, error) {
+			// Avoid the cost of redacting when the payloads would not be logged
+			if !hctx.Logger.Enabled(ctx, slog.LevelDebug) {
+				return next(ctx, hctx, params)
+			}
+
+			hctx.Logger.Debug("request payload", slog.Any("params", redact(params, redactions)))
+
+			result, err := next(ctx, hctx, params)
+			if err == nil {
+				hctx.Logger.Debug("response payload", slog.Any("result", redact(result, redactions)))
+			}
+
+			return result, err
+		}
+	}
+}
+
+// redact returns the JSON representation of v (as generic maps and slices),
+// with the sensitive fields of every struct replaced by [redactedValue].
+func redact(v any, redactions map[string]map[string]struct{}) any {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return "failed to marshal payload: " + err.Error()
+	}
+
+	var generic any
+	if err := json.Unmarshal(data, &generic); err != nil {
+		return "failed to unmarshal payload: " + err.Error()
+	}
+
+	if len(redactions) > 0 {
+		redactValue(generic, reflect.ValueOf(v), redactions)
+	}
+
+	return generic
+}
+
+// redactValue walks the decoded JSON value alongside the Go value it was encoded from, masking sensitive fields.
+// Interfaces are walked as the value they hold, so sensitive structs stored in `any` fields are masked too.
+// Values whose shape does not match the Go value (e.g. custom JSON marshalers) are left as is.
+func redactValue(value any, v reflect.Value, redactions map[string]map[string]struct{}) {
+	for v.Kind() == reflect.Pointer || v.Kind() == reflect.Interface {
+		if v.IsNil() {
+			return
+		}
+
+		v = v.Elem()
+	}
+
+	switch v.Kind() {
+	case reflect.Struct:
+		obj, ok := value.(map[string]any)
+		if !ok {
+			return
+		}
+
+		redactStruct(obj, v, redactions)
+	case reflect.Slice, reflect.Array:
+		items, ok := value.([]any)
+		if !ok || len(items) != v.Len() {
+			return
+		}
+
+		for i, item := range items {
+			redactValue(item, v.Index(i), redactions)
+		}
+	case reflect.Map:
+		obj, ok := value.(map[string]any)
+		if !ok {
+			return
+		}
+
+		iter := v.MapRange()
+		for iter.Next() {
+			key, ok := mapKey(iter.Key())
+			if !ok {
+				continue
+			}
+
+			if item, exists := obj[key]; exists {
+				redactValue(item, iter.Value(), redactions)
+			}
+		}
+	default:
+	}
+}
+
+// mapKey returns the JSON object key encoding/json writes for a map key.
+// Returns false for keys it does not know how to encode.
+func mapKey(key reflect.Value) (string, bool) {
+	if key.Kind() == reflect.String {
+		return key.String(), true
+	}
+
+	if key.CanInterface() {
+		if marshaler, ok := key.Interface().(encoding.TextMarshaler); ok {
+			text, err := marshaler.MarshalText()
+
+			return string(text), err == nil
+		}
+	}
+
+	switch key.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return strconv.FormatInt(key.Int(), 10), true
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		return strconv.FormatUint(key.Uint(), 10), true
+	default:
+		return "", false
+	}
+}
+
+// typeKey returns the key of a named type in the redactions, its package path and name.
+func typeKey(t reflect.Type) string {
+	return t.PkgPath() + "." + t.Name()
+}
+
+// redactStruct masks the sensitive fields of a decoded struct and walks its other fields.
+func redactStruct(obj map[string]any, v reflect.Value, redactions map[string]map[string]struct{}) {
+	t := v.Type()
+	sensitive := redactions[typeKey(t)]
+
+	for i := range t.NumField() {
+		field := t.Field(i)
+		tag := field.Tag.Get("json")
+
+		// Fields of embedded structs without a JSON name are promoted into this object
+		if field.Anonymous && tag == "" {
+			embedded := v.Field(i)
+			for embedded.Kind() == reflect.Pointer && !embedded.IsNil() {
+				embedded = embedded.Elem()
+			}
+
+			if embedded.Kind() == reflect.Struct {
+				redactStruct(obj, embedded, redactions)
+
+				continue
+			}
+		}
+
+		if !field.IsExported() || tag == "-" {
+			continue
+		}
+
+		name, _, _ := strings.Cut(tag, ",")
+		if name == "" {
+			name = field.Name
+		}
+
+		value, exists := obj[name]
+		if !exists {
+			continue
+		}
+
+		if _, ok := sensitive[name]; ok {
+			obj[name] = redactedValue
+
+			continue
+		}
+
+		redactValue(value, v.Field(i), redactions)
+	}
+}
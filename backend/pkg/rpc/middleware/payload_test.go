@@ -0,0 +1,169 @@
+package middleware
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"log/slog"
+	"reflect"
+	"strings"
+	"testing"
+
+	"ws-json-rpc/backend/pkg/rpc"
+)
+
+type Credentials struct {
+	User     string `json:"user"`
+	Password string `json:"password"`
+}
+
+type Embedded struct {
+	Token string `json:"token"`
+}
+
+type Envelope struct {
+	Embedded
+
+	Direct   Credentials            `json:"direct"`
+	Pointer  *Credentials           `json:"pointer"`
+	List     []Credentials          `json:"list"`
+	ByName   map[string]Credentials `json:"byName"`
+	ByNumber map[int]Credentials    `json:"byNumber"`
+	Any      any                    `json:"any"`
+	AnyList  []any                  `json:"anyList"`
+}
+
+// key returns the redaction key of a type declared in this package.
+func key(name string) string {
+	return reflect.TypeFor[Credentials]().PkgPath() + "." + name
+}
+
+// redactJSON redacts v and returns it as JSON, for comparison.
+func redactJSON(t *testing.T, v any, sensitive map[string][]string) string {
+	t.Helper()
+
+	redactions := make(map[string]map[string]struct{}, len(sensitive))
+	for typeName, fields := range sensitive {
+		redactions[typeName] = make(map[string]struct{}, len(fields))
+		for _, field := range fields {
+			redactions[typeName][field] = struct{}{}
+		}
+	}
+
+	data, err := json.Marshal(redact(v, redactions))
+	if err != nil {
+		t.Fatalf("failed to marshal redacted payload: %v", err)
+	}
+
+	return string(data)
+}
+
+func TestRedact(t *testing.T) {
+	creds := Credentials{User: "alice", Password: "hunter2"}
+	sensitive := map[string][]string{key("Credentials"): {"password"}, key("Embedded"): {"token"}}
+
+	tests := []struct {
+		name      string
+		value     any
+		sensitive map[string][]string
+		want      string
+	}{
+		{
+			name:      "struct",
+			value:     creds,
+			sensitive: sensitive,
+			want:      `{"password":"[REDACTED]","user":"alice"}`,
+		},
+		{
+			name:      "pointer",
+			value:     &creds,
+			sensitive: sensitive,
+			want:      `{"password":"[REDACTED]","user":"alice"}`,
+		},
+		{
+			name: "nested",
+			value: Envelope{
+				Embedded: Embedded{Token: "secret"},
+				Direct:   creds,
+				Pointer:  &creds,
+				List:     []Credentials{creds},
+				ByName:   map[string]Credentials{"a": creds},
+				ByNumber: map[int]Credentials{1: creds},
+			},
+			sensitive: sensitive,
+			want: `{"any":null,"anyList":null,` +
+				`"byName":{"a":{"password":"[REDACTED]","user":"alice"}},` +
+				`"byNumber":{"1":{"password":"[REDACTED]","user":"alice"}},` +
+				`"direct":{"password":"[REDACTED]","user":"alice"},` +
+				`"list":[{"password":"[REDACTED]","user":"alice"}],` +
+				`"pointer":{"password":"[REDACTED]","user":"alice"},` +
+				`"token":"[REDACTED]"}`,
+		},
+		{
+			name:      "interface fields",
+			value:     Envelope{Any: creds, AnyList: []any{"plain", &creds}},
+			sensitive: sensitive,
+			want: `{"any":{"password":"[REDACTED]","user":"alice"},` +
+				`"anyList":["plain",{"password":"[REDACTED]","user":"alice"}],` +
+				`"byName":null,"byNumber":null,"direct":{"password":"[REDACTED]","user":""},"list":null,"pointer":null,"token":"[REDACTED]"}`,
+		},
+		{
+			name:      "top level interface slice",
+			value:     []any{creds},
+			sensitive: sensitive,
+			want:      `[{"password":"[REDACTED]","user":"alice"}]`,
+		},
+		{
+			name:      "same name in another package",
+			value:     creds,
+			sensitive: map[string][]string{"example.com/other.Credentials": {"password"}},
+			want:      `{"password":"hunter2","user":"alice"}`,
+		},
+		{
+			name:      "bare type name",
+			value:     creds,
+			sensitive: map[string][]string{"Credentials": {"password"}},
+			want:      `{"password":"hunter2","user":"alice"}`,
+		},
+		{
+			name:      "nil",
+			value:     nil,
+			sensitive: sensitive,
+			want:      `null`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := redactJSON(t, tt.value, tt.sensitive); got != tt.want {
+				t.Errorf("redact() =\n%s\nwant\n%s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestPayloadLogMiddleware(t *testing.T) {
+	var logs bytes.Buffer
+
+	hctx := &rpc.HandlerContext{Logger: slog.New(slog.NewJSONHandler(&logs, &slog.HandlerOptions{Level: slog.LevelDebug}))}
+
+	handler := PayloadLogMiddleware(map[string][]string{key("Credentials"): {"password"}})(
+		func(_ context.Context, _ *rpc.HandlerContext, params any) (any, error) {
+			return struct {
+				Saved any `json:"saved"`
+			}{Saved: params}, nil
+		},
+	)
+
+	if _, err := handler(t.Context(), hctx, Credentials{User: "alice", Password: "hunter2"}); err != nil {
+		t.Fatalf("handler() error = %v", err)
+	}
+
+	if strings.Contains(logs.String(), "hunter2") {
+		t.Errorf("logged payloads contain a sensitive value:\n%s", logs.String())
+	}
+
+	if got := strings.Count(logs.String(), redactedValue); got != 2 {
+		t.Errorf("logged payloads hold %d redacted values, want 2 (params and result):\n%s", got, logs.String())
+	}
+}
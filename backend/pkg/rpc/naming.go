@@ -0,0 +1,37 @@
+package rpc
+
+import (
+	"fmt"
+	"regexp"
+)
+
+// NamePolicy is a naming convention that registered event and method names must follow.
+type NamePolicy struct {
+	Name    string         // Name of the convention, used in error messages
+	Pattern *regexp.Regexp // Pattern a conforming name must fully match
+}
+
+var (
+	// NamePolicyCamelCase accepts names like "ping" or "userCreate".
+	NamePolicyCamelCase = NamePolicy{Name: "camelCase", Pattern: regexp.MustCompile(`^[a-z][a-zA-Z0-9]*$`)}
+	// NamePolicyDotCase accepts lowercase names with dot separated segments, like "ping" or "user.create".
+	NamePolicyDotCase = NamePolicy{Name: "dot.case", Pattern: regexp.MustCompile(`^[a-z][a-z0-9]*(\.[a-z][a-z0-9]*)*$`)}
+)
+
+// NewNamePolicy creates a naming policy from a regular expression, which is anchored to match whole names.
+func NewNamePolicy(expr string) (NamePolicy, error) {
+	if _, err := regexp.Compile(expr); err != nil {
+		return NamePolicy{}, fmt.Errorf("invalid name policy pattern: %w", err)
+	}
+
+	return NamePolicy{Name: expr, Pattern: regexp.MustCompile(`^(?:` + expr + `)$`)}, nil
+}
+
+// Check returns an error if the name does not follow the policy.
+func (p NamePolicy) Check(name string) error {
+	if p.Pattern.MatchString(name) {
+		return nil
+	}
+
+	return fmt.Errorf("name %q does not follow the %s naming policy", name, p.Name)
+}
@@ -0,0 +1,54 @@
+package rpc
+
+import "net/http"
+
+// ItemError - The error of a single item of a batch operation, see [PartialResult].
+type ItemError struct {
+	// Position of the failed item in the params
+	Index int `json:"index"`
+	// Error code, with the same meaning as the JSON-RPC error codes
+	Code int `json:"code"`
+	// Human-readable error message
+	Message string `json:"message"`
+}
+
+// PartialResult - Result of batch methods that may partially succeed.
+// The method call succeeds as long as the batch was processed, the failed items are listed in errors.
+// Methods return a named type of it, e.g. "type UserDeleteResult rpc.PartialResult[uuid.UUID]", built with
+// [NewPartialResult] and converted once all items are processed. Over HTTP, pass [PartialResult.Status]
+// to [HandlerContext.SetStatus] to answer with 207 Multi-Status when some items failed.
+type PartialResult[T any] struct {
+	// Results of the succeeded items, in params order
+	Results []T `json:"results"`
+	// Errors of the failed items, in params order
+	Errors []ItemError `json:"errors"`
+}
+
+// NewPartialResult creates an empty [PartialResult], with non-nil slices so they are never null.
+func NewPartialResult[T any]() PartialResult[T] {
+	return PartialResult[T]{Results: []T{}, Errors: []ItemError{}}
+}
+
+// AddResult records the result of a succeeded item.
+func (r *PartialResult[T]) AddResult(result T) {
+	r.Results = append(r.Results, result)
+}
+
+// AddError records the error of the failed item at the given position of the params.
+func (r *PartialResult[T]) AddError(index int, code int, message string) {
+	r.Errors = append(r.Errors, ItemError{Index: index, Code: code, Message: message})
+}
+
+// Failed returns true if any item failed.
+func (r *PartialResult[T]) Failed() bool {
+	return len(r.Errors) > 0
+}
+
+// Status returns the HTTP status of the result, 207 Multi-Status if any item failed, otherwise 200 OK.
+func (r *PartialResult[T]) Status() int {
+	if r.Failed() {
+		return http.StatusMultiStatus
+	}
+
+	return http.StatusOK
+}
@@ -0,0 +1,207 @@
+package rpc
+
+import (
+	"context"
+	"crypto/rand"
+	"log/slog"
+	"maps"
+	"sync"
+	"time"
+	"ws-json-rpc/backend/pkg/utils"
+)
+
+const (
+	// SESSION_METHOD is the notification issuing the session token to a WebSocket client, see [HubOptions.SessionStore].
+	SESSION_METHOD = "$session"
+	// SESSION_QUERY_PARAM is the query parameter a reconnecting WebSocket client presents its session token in.
+	SESSION_QUERY_PARAM = "session"
+	// DEFAULT_SESSION_TTL is how long a session can be resumed after the client disconnects, see [HubOptions.SessionTTL].
+	DEFAULT_SESSION_TTL = 5 * time.Minute
+)
+
+// Session is the state of a WebSocket client kept for it to resume after reconnecting.
+type Session struct {
+	Identity      string    // Identity of the client, a session only resumes for the same identity
+	Subscriptions []string  // Events the client was subscribed to
+	ExpiresAt     time.Time // After this, the session can not be resumed
+}
+
+// SessionStore keeps the sessions of WebSocket clients by their token, see [HubOptions.SessionStore].
+// Use a shared store (e.g. backed by a database) to resume sessions across server instances.
+type SessionStore interface {
+	// Save stores a session under its token, replacing any existing one.
+	Save(ctx context.Context, token string, session Session) error
+	// Take returns the session of a token and removes it, so every token is used at most once.
+	// Returns false if there is no such session. Expired sessions may be returned, the hub rejects them.
+	Take(ctx context.Context, token string) (Session, bool, error)
+}
+
+// SessionInfo is the params of the [SESSION_METHOD] notification.
+type SessionInfo struct {
+	Token         string    `json:"token"`         // Token to present in the "session" query parameter when reconnecting
+	ExpiresAt     time.Time `json:"expiresAt"`     // Earliest expiry of the token, it is extended to SessionTTL after disconnecting
+	Resumed       bool      `json:"resumed"`       // Whether the session of the presented token was resumed
+	Subscriptions []string  `json:"subscriptions"` // Events the client is subscribed to, restored from the resumed session
+}
+
+// MemorySessionStore is a [SessionStore] keeping sessions in memory, lost when the server restarts.
+type MemorySessionStore struct {
+	sessions map[string]Session
+	mu       sync.Mutex
+}
+
+// NewMemorySessionStore creates an empty in-memory session store.
+func NewMemorySessionStore() *MemorySessionStore {
+	return &MemorySessionStore{sessions: make(map[string]Session)}
+}
+
+// Save stores a session, dropping expired ones.
+func (s *MemorySessionStore) Save(_ context.Context, token string, session Session) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := time.Now()
+	maps.DeleteFunc(s.sessions, func(_ string, session Session) bool {
+		return now.After(session.ExpiresAt)
+	})
+
+	s.sessions[token] = session
+
+	return nil
+}
+
+// Take returns and removes a session.
+func (s *MemorySessionStore) Take(_ context.Context, token string) (Session, bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	session, ok := s.sessions[token]
+	delete(s.sessions, token)
+
+	return session, ok, nil
+}
+
+// newSessionToken returns a random, unguessable session token (128 bits), safe to use in URLs.
+func newSessionToken() string {
+	return rand.Text()
+}
+
+// startSession resumes the session of the presented token, if valid, and issues a new token to the client.
+// Invalid, expired or foreign tokens are ignored, the client starts a fresh session and resubscribes itself.
+// The new token is only saved to the store when the client disconnects, until then it can be handed over
+// from the connected client (see [Hub.takeSession]).
+func (h *Hub) startSession(ctx context.Context, client *WSClient, token string) {
+	info := SessionInfo{Subscriptions: []string{}}
+
+	if token != "" {
+		session, ok, err := h.takeSession(ctx, client, token)
+
+		switch {
+		case err != nil:
+			client.logger.Error("failed to load session", utils.ErrAttr(err))
+		case !ok || time.Now().After(session.ExpiresAt):
+			client.logger.Warn("unknown or expired session token, starting a new session")
+		case session.Identity != client.identity:
+			client.logger.Warn("session token of another identity, starting a new session")
+		default:
+			info.Resumed = true
+			// The events may have been removed since
+			info.Subscriptions = h.registeredEvents(session.Subscriptions)
+		}
+	}
+
+	info.Token = newSessionToken()
+	info.ExpiresAt = time.Now().Add(h.opts.SessionTTL)
+
+	h.clientsMutex.Lock()
+	client.sessionToken = info.Token
+	h.sessionClients[info.Token] = client
+	h.clientsMutex.Unlock()
+
+	msg, err := utils.ToJSON(struct {
+		Version string      `json:"jsonrpc"`
+		Method  string      `json:"method"`
+		Params  SessionInfo `json:"params"`
+	}{Version: "2.0", Method: SESSION_METHOD, Params: info})
+	if err != nil {
+		client.logger.Error("failed to marshal session notification", utils.ErrAttr(err))
+
+		return
+	}
+
+	// Queued before restoring the subscriptions, the send channel is empty as nothing
+	// can send to the client until it subscribes, and the write pump is not started yet
+	client.sendChannel <- msg
+
+	for _, event := range info.Subscriptions {
+		if err := h.Subscribe(client, event); err != nil {
+			client.logger.Warn("failed to restore subscription", slog.String("event", event), utils.ErrAttr(err))
+		}
+	}
+
+	if info.Resumed {
+		client.logger.Info("session resumed", slog.Int("subscriptions", len(info.Subscriptions)))
+	}
+}
+
+// takeSession returns the session of a token. A token still held by a connected client of the same identity,
+// like one reconnecting before its old connection was reaped, is handed over from that client, which is then
+// closed with [CloseSessionResumed] and does not save it when it disconnects. Otherwise the session is taken from the store.
+func (h *Hub) takeSession(ctx context.Context, client *WSClient, token string) (Session, bool, error) {
+	h.clientsMutex.Lock()
+
+	holder, connected := h.sessionClients[token]
+	handover := connected && holder.identity == client.identity
+
+	if handover {
+		delete(h.sessionClients, token)
+		holder.sessionToken = ""
+	}
+
+	h.clientsMutex.Unlock()
+
+	if connected {
+		session := Session{Identity: holder.identity, Subscriptions: h.Subscriptions(holder), ExpiresAt: time.Now().Add(h.opts.SessionTTL)}
+
+		if handover {
+			// Closing waits for the old connection to acknowledge, which may never happen if it is dead
+			go func() {
+				if err := holder.Close(CloseSessionResumed); err != nil {
+					holder.logger.Debug("failed to close connection", utils.ErrAttr(err))
+				}
+			}()
+		}
+
+		return session, true, nil
+	}
+
+	return h.opts.SessionStore.Take(ctx, token)
+}
+
+// registeredEvents returns the given events that are registered.
+func (h *Hub) registeredEvents(events []string) []string {
+	h.subscriptionsMutex.RLock()
+	defer h.subscriptionsMutex.RUnlock()
+
+	registered := make([]string, 0, len(events))
+
+	for _, event := range events {
+		if _, ok := h.subscriptions[event]; ok {
+			registered = append(registered, event)
+		}
+	}
+
+	return registered
+}
+
+// saveSession stores the subscriptions of a disconnected client under its session token,
+// which can then be resumed until [HubOptions.SessionTTL] elapses.
+func (h *Hub) saveSession(client *WSClient, token string, subscriptions []string) {
+	ctx, cancel := context.WithTimeout(context.Background(), MAX_REQUEST_TIMEOUT)
+	defer cancel()
+
+	session := Session{Identity: client.identity, Subscriptions: subscriptions, ExpiresAt: time.Now().Add(h.opts.SessionTTL)}
+	if err := h.opts.SessionStore.Save(ctx, token, session); err != nil {
+		client.logger.Error("failed to save session", utils.ErrAttr(err))
+	}
+}
@@ -0,0 +1,217 @@
+package rpc
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/coder/websocket"
+)
+
+// sessionTestServer serves a test hub with resumable sessions, identifying callers by their "X-Identity" header.
+type sessionTestServer struct {
+	store  *MemorySessionStore
+	server *httptest.Server
+}
+
+func newSessionTestServer(t *testing.T) *sessionTestServer {
+	t.Helper()
+
+	store := NewMemorySessionStore()
+	h := newTestHub(t, HubOptions{
+		SessionStore: store,
+		IdentityFunc: func(r *http.Request) (string, error) {
+			return r.Header.Get("X-Identity"), nil
+		},
+	})
+
+	RegisterMethod(h, "subscribe", func(_ context.Context, hctx *HandlerContext, params echoParams) (struct{}, error) {
+		return struct{}{}, h.Subscribe(hctx.WSConn, params.Message)
+	}, RegisterMethodOptions{})
+
+	server := httptest.NewServer(h.ServeWS())
+	t.Cleanup(server.Close)
+
+	return &sessionTestServer{store: store, server: server}
+}
+
+// connect dials the server as the given identity, presenting the session token if not empty,
+// and returns the connection with its session notification.
+func (s *sessionTestServer) connect(ctx context.Context, t *testing.T, identity, token string) (*websocket.Conn, SessionInfo) {
+	t.Helper()
+
+	url := "ws" + strings.TrimPrefix(s.server.URL, "http")
+	if token != "" {
+		url += "?" + SESSION_QUERY_PARAM + "=" + token
+	}
+
+	conn, _, err := websocket.Dial(ctx, url, &websocket.DialOptions{HTTPHeader: http.Header{"X-Identity": {identity}}})
+	if err != nil {
+		t.Fatalf("Dial() error = %v", err)
+	}
+
+	t.Cleanup(func() { conn.CloseNow() })
+
+	_, data, err := conn.Read(ctx)
+	if err != nil {
+		t.Fatalf("Read() error = %v", err)
+	}
+
+	var notification struct {
+		Method string      `json:"method"`
+		Params SessionInfo `json:"params"`
+	}
+	if err := json.Unmarshal(data, &notification); err != nil {
+		t.Fatalf("failed to decode session notification %s: %v", data, err)
+	}
+
+	if notification.Method != SESSION_METHOD || notification.Params.Token == "" {
+		t.Fatalf("first message = %s, want a %s notification with a token", data, SESSION_METHOD)
+	}
+
+	return conn, notification.Params
+}
+
+// subscribe subscribes the connection to the "ping" event.
+func subscribe(ctx context.Context, t *testing.T, conn *websocket.Conn) {
+	t.Helper()
+
+	request := `{"jsonrpc":"2.0","id":"1","method":"subscribe","params":{"message":"ping"}}`
+	if err := conn.Write(ctx, websocket.MessageText, []byte(request)); err != nil {
+		t.Fatalf("Write() error = %v", err)
+	}
+
+	_, data, err := conn.Read(ctx)
+	if err != nil {
+		t.Fatalf("Read() error = %v", err)
+	}
+
+	var resp RPCResponse
+	if err := json.Unmarshal(data, &resp); err != nil || resp.Error != nil {
+		t.Fatalf("subscribe response = %s, want a result", data)
+	}
+}
+
+// disconnect closes the connection and waits for the hub to save its session.
+func (s *sessionTestServer) disconnect(ctx context.Context, t *testing.T, conn *websocket.Conn, token string) {
+	t.Helper()
+
+	conn.Close(websocket.StatusNormalClosure, "")
+
+	for {
+		s.store.mu.Lock()
+		_, saved := s.store.sessions[token]
+		s.store.mu.Unlock()
+
+		if saved {
+			return
+		}
+
+		select {
+		case <-ctx.Done():
+			t.Fatal("session was not saved after disconnecting")
+		case <-time.After(10 * time.Millisecond):
+		}
+	}
+}
+
+func TestSessionResume(t *testing.T) {
+	s := newSessionTestServer(t)
+
+	ctx, cancel := context.WithTimeout(t.Context(), 5*time.Second)
+	defer cancel()
+
+	conn, first := s.connect(ctx, t, "alice", "")
+	if first.Resumed || len(first.Subscriptions) != 0 {
+		t.Errorf("new session = %+v, want not resumed without subscriptions", first)
+	}
+
+	subscribe(ctx, t, conn)
+	s.disconnect(ctx, t, conn, first.Token)
+
+	_, resumed := s.connect(ctx, t, "alice", first.Token)
+	if !resumed.Resumed || !slices.Equal(resumed.Subscriptions, []string{"ping"}) {
+		t.Errorf("resumed session = %+v, want resumed with [ping]", resumed)
+	}
+
+	if resumed.Token == first.Token {
+		t.Error("resumed session token = the presented token, want a new one")
+	}
+
+	// Tokens are single use
+	_, reused := s.connect(ctx, t, "alice", first.Token)
+	if reused.Resumed {
+		t.Error("session resumed twice with the same token")
+	}
+}
+
+func TestSessionRejected(t *testing.T) {
+	tests := []struct {
+		name    string
+		session Session
+	}{
+		{name: "expired", session: Session{Identity: "alice", Subscriptions: []string{"ping"}, ExpiresAt: time.Now().Add(-time.Second)}},
+		{name: "foreign identity", session: Session{Identity: "bob", Subscriptions: []string{"ping"}, ExpiresAt: time.Now().Add(time.Minute)}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := newSessionTestServer(t)
+
+			ctx, cancel := context.WithTimeout(t.Context(), 5*time.Second)
+			defer cancel()
+
+			if err := s.store.Save(ctx, "token", tt.session); err != nil {
+				t.Fatalf("Save() error = %v", err)
+			}
+
+			_, info := s.connect(ctx, t, "alice", "token")
+			if info.Resumed || len(info.Subscriptions) != 0 {
+				t.Errorf("session = %+v, want a new session without subscriptions", info)
+			}
+
+			// Rejected tokens are consumed too
+			if _, ok, _ := s.store.Take(ctx, "token"); ok {
+				t.Error("rejected session is still in the store")
+			}
+		})
+	}
+}
+
+func TestSessionHandover(t *testing.T) {
+	s := newSessionTestServer(t)
+
+	ctx, cancel := context.WithTimeout(t.Context(), 5*time.Second)
+	defer cancel()
+
+	old, first := s.connect(ctx, t, "alice", "")
+	subscribe(ctx, t, old)
+
+	// A connection of another identity can not take the session over
+	_, foreign := s.connect(ctx, t, "bob", first.Token)
+	if foreign.Resumed {
+		t.Error("session handed over to another identity")
+	}
+
+	// The old connection is still open, its session is handed over
+	_, resumed := s.connect(ctx, t, "alice", first.Token)
+	if !resumed.Resumed || !slices.Equal(resumed.Subscriptions, []string{"ping"}) {
+		t.Errorf("handed over session = %+v, want resumed with [ping]", resumed)
+	}
+
+	_, _, err := old.Read(ctx)
+	if status := websocket.CloseStatus(err); status != CloseSessionResumed.Code {
+		t.Errorf("old connection Read() error = %v, want close status %d", err, CloseSessionResumed.Code)
+	}
+
+	var closeErr websocket.CloseError
+	if errors.As(err, &closeErr) && closeErr.Reason != CloseSessionResumed.Reason {
+		t.Errorf("old connection close reason = %q, want %q", closeErr.Reason, CloseSessionResumed.Reason)
+	}
+}
@@ -0,0 +1,197 @@
+package rpc
+
+import (
+	"crypto/sha256"
+	"encoding"
+	"encoding/hex"
+	"encoding/json"
+	"reflect"
+	"slices"
+	"strings"
+)
+
+// SignatureHash returns a hash of the names and JSON shapes of the registered methods (including aliases)
+// and events. It only changes when the API does, so clients can compare it to detect schema drift.
+// The hash is deterministic: it does not depend on registration order or field order. It does not depend
+// on Go type names either, except for types with custom marshaling and recursive structs, which are hashed by
+// their name (see typeSignature).
+func (h *Hub) SignatureHash() string {
+	var lines []string
+
+	h.methodsMutex.RLock()
+	for name, method := range h.methods {
+		lines = append(lines, "method "+name+" "+method.signature)
+	}
+	h.methodsMutex.RUnlock()
+
+	h.subscriptionsMutex.RLock()
+	for name, signature := range h.eventSignatures {
+		lines = append(lines, "event "+name+" "+signature)
+	}
+	h.subscriptionsMutex.RUnlock()
+
+	slices.Sort(lines)
+
+	sum := sha256.Sum256([]byte(strings.Join(lines, "\n")))
+
+	return hex.EncodeToString(sum[:])
+}
+
+var (
+	jsonMarshalerType = reflect.TypeFor[json.Marshaler]()
+	textMarshalerType = reflect.TypeFor[encoding.TextMarshaler]()
+)
+
+// typeSignature describes the JSON shape of a Go type, following the encoding/json rules, e.g.
+// `{id:string;name?:string;tags:[]string|null}`, with struct fields sorted by JSON name.
+// Types with custom marshaling and recursive references to a struct are described by their Go type name.
+func typeSignature(t reflect.Type) string {
+	var b strings.Builder
+
+	writeTypeSignature(&b, t, nil)
+
+	return b.String()
+}
+
+// writeTypeSignature writes the signature of t, visiting lists the structs being described to stop recursion.
+func writeTypeSignature(b *strings.Builder, t reflect.Type, visiting []reflect.Type) {
+	if hasCustomMarshaling(t) {
+		b.WriteString(t.String())
+
+		return
+	}
+
+	switch t.Kind() {
+	case reflect.Pointer:
+		writeTypeSignature(b, t.Elem(), visiting)
+		b.WriteString("|null")
+	case reflect.Interface:
+		b.WriteString("any")
+	case reflect.Bool:
+		b.WriteString("boolean")
+	case reflect.String:
+		b.WriteString("string")
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr,
+		reflect.Float32, reflect.Float64:
+		b.WriteString("number")
+	case reflect.Slice, reflect.Array:
+		// Byte slices are encoded as base64 strings
+		if t.Kind() == reflect.Slice && t.Elem().Kind() == reflect.Uint8 {
+			b.WriteString("string")
+
+			return
+		}
+
+		b.WriteString("[]")
+		writeTypeSignature(b, t.Elem(), visiting)
+
+		if t.Kind() == reflect.Slice {
+			b.WriteString("|null")
+		}
+	case reflect.Map:
+		b.WriteString("{[")
+		writeTypeSignature(b, t.Key(), visiting)
+		b.WriteString("]:")
+		writeTypeSignature(b, t.Elem(), visiting)
+		b.WriteString("}|null")
+	case reflect.Struct:
+		if slices.Contains(visiting, t) {
+			b.WriteString(t.String())
+
+			return
+		}
+
+		b.WriteString("{")
+		writeStructFields(b, t, append(visiting, t))
+		b.WriteString("}")
+	default:
+		// Channels and functions can not be encoded
+		b.WriteString("invalid")
+	}
+}
+
+// hasCustomMarshaling returns true if encoding/json marshals t with a json.Marshaler or encoding.TextMarshaler.
+// Marshalers with pointer receivers count too, as they are used for addressable values, like struct fields.
+func hasCustomMarshaling(t reflect.Type) bool {
+	ptr := reflect.PointerTo(t)
+
+	return t.Implements(jsonMarshalerType) || t.Implements(textMarshalerType) ||
+		ptr.Implements(jsonMarshalerType) || ptr.Implements(textMarshalerType)
+}
+
+// structField is the signature of an encoded struct field.
+type structField struct {
+	name      string
+	signature string
+}
+
+// writeStructFields writes the encoded fields of a struct sorted by JSON name, so the order of the Go fields
+// does not matter.
+func writeStructFields(b *strings.Builder, t reflect.Type, visiting []reflect.Type) {
+	fields := structFields(t, visiting)
+	slices.SortStableFunc(fields, func(a, b structField) int { return strings.Compare(a.name, b.name) })
+
+	for _, field := range fields {
+		b.WriteString(field.signature)
+		b.WriteString(";")
+	}
+}
+
+// structFields returns the encoded fields of a struct, e.g. `name?:string`, inlining embedded structs without a json name.
+func structFields(t reflect.Type, visiting []reflect.Type) []structField {
+	var fields []structField
+
+	for i := range t.NumField() {
+		field := t.Field(i)
+
+		tag := field.Tag.Get("json")
+		if tag == "-" {
+			continue
+		}
+
+		name, opts, _ := strings.Cut(tag, ",")
+		options := strings.Split(opts, ",")
+
+		if field.Anonymous && name == "" {
+			embedded := field.Type
+			if embedded.Kind() == reflect.Pointer {
+				embedded = embedded.Elem()
+			}
+
+			if embedded.Kind() == reflect.Struct {
+				fields = append(fields, structFields(embedded, visiting)...)
+
+				continue
+			}
+		}
+
+		if !field.IsExported() {
+			continue
+		}
+
+		if name == "" {
+			name = field.Name
+		}
+
+		var b strings.Builder
+
+		b.WriteString(name)
+
+		if slices.Contains(options, "omitempty") || slices.Contains(options, "omitzero") {
+			b.WriteString("?")
+		}
+
+		b.WriteString(":")
+
+		if slices.Contains(options, "string") {
+			b.WriteString("string")
+		} else {
+			writeTypeSignature(&b, field.Type, visiting)
+		}
+
+		fields = append(fields, structField{name: name, signature: b.String()})
+	}
+
+	return fields
+}
@@ -0,0 +1,95 @@
+package rpc
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"reflect"
+	"slices"
+	"testing"
+
+	"ws-json-rpc/backend/pkg/rpc/generate"
+)
+
+type signatureAB struct {
+	A string `json:"a"`
+	B int    `json:"b,omitempty"`
+}
+
+type signatureBA struct {
+	B int    `json:"b,omitempty"`
+	A string `json:"a"`
+}
+
+// signatureText is marshaled as text through a pointer receiver.
+type signatureText struct {
+	value string
+}
+
+func (s *signatureText) MarshalText() ([]byte, error) {
+	return []byte(s.value), nil
+}
+
+type signatureNode struct {
+	Children []signatureNode `json:"children"`
+}
+
+func TestTypeSignature(t *testing.T) {
+	tests := []struct {
+		name string
+		typ  any
+		want string
+	}{
+		{name: "fields sorted by json name", typ: signatureBA{}, want: "{a:string;b?:number;}"},
+		{name: "pointer receiver marshaler", typ: struct {
+			Text signatureText `json:"text"`
+		}{}, want: "{text:rpc.signatureText;}"},
+		{name: "recursive struct", typ: signatureNode{}, want: "{children:[]rpc.signatureNode|null;}"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := typeSignature(reflect.TypeOf(tt.typ)); got != tt.want {
+				t.Errorf("typeSignature() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+
+	if ab, ba := typeSignature(reflect.TypeOf(signatureAB{})), typeSignature(reflect.TypeOf(signatureBA{})); ab != ba {
+		t.Errorf("typeSignature() depends on field order: %q != %q", ab, ba)
+	}
+}
+
+func TestSignatureHashStable(t *testing.T) {
+	newHub := func(reversed bool) *Hub {
+		h := NewHub(slog.New(slog.NewTextHandler(io.Discard, nil)), &generate.MockGenerator{}, HubOptions{})
+
+		registrations := []func(){
+			func() {
+				RegisterMethod(h, "first", func(context.Context, *HandlerContext, signatureAB) (signatureAB, error) {
+					return signatureAB{}, nil
+				}, RegisterMethodOptions{})
+			},
+			func() {
+				RegisterMethod(h, "second", func(context.Context, *HandlerContext, signatureBA) (signatureNode, error) {
+					return signatureNode{}, nil
+				}, RegisterMethodOptions{})
+			},
+			func() { RegisterEvent[signatureAB](h, "changed", EventOptions{}) },
+		}
+
+		if reversed {
+			slices.Reverse(registrations)
+		}
+
+		for _, register := range registrations {
+			register()
+		}
+
+		return h
+	}
+
+	if forward, reversed := newHub(false).SignatureHash(), newHub(true).SignatureHash(); forward != reversed {
+		t.Errorf("SignatureHash() depends on registration order: %s != %s", forward, reversed)
+	}
+}
@@ -0,0 +1,75 @@
+package rpc
+
+import "unicode/utf8"
+
+const (
+	// MAX_SUGGESTION_DISTANCE is the maximum edit distance of a method name suggested for an unknown method.
+	MAX_SUGGESTION_DISTANCE = 3
+	// MAX_SUGGESTION_NAME_LENGTH is the length in bytes of the longest unknown method name suggestions are looked up for.
+	// The name comes from the client, so longer ones are not compared against every method.
+	MAX_SUGGESTION_NAME_LENGTH = 128
+)
+
+// suggestMethod returns the registered method name closest to the given unknown name, for "did you mean" hints.
+// Canonical methods are preferred over aliases at equal distance. Returns false if no name is close enough,
+// names are considered close if about a third of their characters (at most [MAX_SUGGESTION_DISTANCE]) differ.
+func (h *Hub) suggestMethod(name string) (string, bool) {
+	if len(name) > MAX_SUGGESTION_NAME_LENGTH {
+		return "", false
+	}
+
+	runes := []rune(name)
+	// Allow a swapped pair of characters in short names, but never replacing all of them
+	maxDistance := min(MAX_SUGGESTION_DISTANCE, max(2, len(runes)/3), len(runes)-1)
+
+	best, bestDistance := "", maxDistance+1
+	for _, method := range h.Methods() {
+		// The distance is at least the difference in length
+		if abs(utf8.RuneCountInString(method.Name)-len(runes)) > maxDistance {
+			continue
+		}
+
+		if distance := levenshtein(runes, []rune(method.Name)); distance < bestDistance {
+			best, bestDistance = method.Name, distance
+		}
+	}
+
+	return best, best != ""
+}
+
+// abs returns the absolute value of n.
+func abs(n int) int {
+	if n < 0 {
+		return -n
+	}
+
+	return n
+}
+
+// levenshtein returns the number of single character insertions, deletions and substitutions between a and b.
+func levenshtein(ra, rb []rune) int {
+	// Only keep the previous row of the distance matrix
+	prev := make([]int, len(rb)+1)
+	curr := make([]int, len(rb)+1)
+
+	for j := range prev {
+		prev[j] = j
+	}
+
+	for i := 1; i <= len(ra); i++ {
+		curr[0] = i
+
+		for j := 1; j <= len(rb); j++ {
+			cost := 1
+			if ra[i-1] == rb[j-1] {
+				cost = 0
+			}
+
+			curr[j] = min(prev[j]+1, curr[j-1]+1, prev[j-1]+cost)
+		}
+
+		prev, curr = curr, prev
+	}
+
+	return prev[len(rb)]
+}
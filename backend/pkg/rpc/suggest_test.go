@@ -0,0 +1,31 @@
+package rpc
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestSuggestMethod(t *testing.T) {
+	h := newTestHub(t, HubOptions{})
+
+	tests := []struct {
+		name string
+		want string
+	}{
+		{name: "ehco", want: "echo"},
+		{name: "fial", want: "fail"},
+		{name: "echoes", want: "echo"},
+		{name: "unrelated", want: ""},
+		{name: "e", want: ""},
+		{name: strings.Repeat("echo", MAX_SUGGESTION_NAME_LENGTH), want: ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name[:min(len(tt.name), 16)], func(t *testing.T) {
+			got, ok := h.suggestMethod(tt.name)
+			if got != tt.want || ok != (tt.want != "") {
+				t.Errorf("suggestMethod(%q) = %q, %v, want %q", tt.name, got, ok, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,164 @@
+package rpc
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strings"
+)
+
+// Union encodes and decodes the values of an interface type by a discriminator field, set to a different
+// value in every variant. It is the Go side of the TypeScript unions generated for interfaces annotated
+// with "@union <field>", whose variants annotate the field with "@const <value>":
+//
+//	// Shape is a shape.
+//	//
+//	// @union kind
+//	type Shape interface{ isShape() }
+//
+//	type Circle struct {
+//		// @const circle
+//		Kind   string  `json:"kind"`
+//		Radius float64 `json:"radius"`
+//	}
+//
+//	shapes := rpc.NewUnion[Shape]("kind")
+//	err := rpc.AddVariant[Shape, Circle](shapes, "circle")
+//
+// Params and results hold union values as [json.RawMessage], decoded with [Union.Unmarshal].
+type Union[T any] struct {
+	field    string
+	byValue  map[string]reflect.Type
+	variants map[reflect.Type]unionVariant
+}
+
+// unionVariant is a registered variant of a [Union].
+type unionVariant struct {
+	value string
+	field []int // Index of the discriminator field in the struct
+}
+
+// NewUnion creates a union of the interface type T, with the given JSON name of the discriminator field.
+func NewUnion[T any](field string) *Union[T] {
+	return &Union[T]{
+		field:    field,
+		byValue:  make(map[string]reflect.Type),
+		variants: make(map[reflect.Type]unionVariant),
+	}
+}
+
+// AddVariant registers V as the variant of the union with the given discriminator value. V is a struct,
+// or a pointer to one if its methods implementing T have pointer receivers, with a string field of the
+// discriminator's JSON name. Returns an error if V does not fit, or the value is already registered.
+func AddVariant[T any, V any](u *Union[T], value string) error {
+	variantType := reflect.TypeFor[V]()
+
+	if !variantType.Implements(reflect.TypeFor[T]()) {
+		return fmt.Errorf("variant %s does not implement %s", variantType, reflect.TypeFor[T]())
+	}
+
+	structType := variantType
+	if structType.Kind() == reflect.Pointer {
+		structType = structType.Elem()
+	}
+
+	if structType.Kind() != reflect.Struct {
+		return fmt.Errorf("variant %s is not a struct", variantType)
+	}
+
+	field, ok := discriminatorField(structType, u.field)
+	if !ok {
+		return fmt.Errorf("variant %s has no string field %q", variantType, u.field)
+	}
+
+	if other, ok := u.byValue[value]; ok {
+		return fmt.Errorf("discriminator %q is already registered for variant %s", value, other)
+	}
+
+	u.byValue[value] = variantType
+	u.variants[variantType] = unionVariant{value: value, field: field}
+
+	return nil
+}
+
+// discriminatorField returns the index of the string field of a struct with the given JSON name.
+func discriminatorField(t reflect.Type, name string) ([]int, bool) {
+	for _, field := range reflect.VisibleFields(t) {
+		if !field.IsExported() || field.Type.Kind() != reflect.String {
+			continue
+		}
+
+		tag, _, _ := strings.Cut(field.Tag.Get("json"), ",")
+		if tag == name || (tag == "" && field.Name == name) {
+			return field.Index, true
+		}
+	}
+
+	return nil, false
+}
+
+// Marshal encodes a value of a registered variant, with the discriminator field set to its value.
+func (u *Union[T]) Marshal(value T) ([]byte, error) {
+	rv := reflect.ValueOf(value)
+	if !rv.IsValid() {
+		return nil, fmt.Errorf("can not marshal nil %s", reflect.TypeFor[T]())
+	}
+
+	variant, ok := u.variants[rv.Type()]
+	if !ok {
+		return nil, fmt.Errorf("%s is not a registered variant of %s", rv.Type(), reflect.TypeFor[T]())
+	}
+
+	// Set the discriminator on a copy, leaving the value untouched
+	if rv.Kind() == reflect.Pointer {
+		rv = rv.Elem()
+	}
+
+	clone := reflect.New(rv.Type()).Elem()
+	clone.Set(rv)
+	clone.FieldByIndex(variant.field).SetString(variant.value)
+
+	return json.Marshal(clone.Interface())
+}
+
+// Unmarshal decodes a value into the variant its discriminator field names.
+// Returns an error if the field is missing or names no registered variant.
+func (u *Union[T]) Unmarshal(data []byte) (T, error) {
+	var zero T
+
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal(data, &fields); err != nil {
+		return zero, fmt.Errorf("failed to decode %s: %w", reflect.TypeFor[T](), err)
+	}
+
+	raw, ok := fields[u.field]
+	if !ok {
+		return zero, fmt.Errorf("missing discriminator field %q", u.field)
+	}
+
+	var value string
+	if err := json.Unmarshal(raw, &value); err != nil {
+		return zero, fmt.Errorf("discriminator field %q is not a string", u.field)
+	}
+
+	variantType, ok := u.byValue[value]
+	if !ok {
+		return zero, fmt.Errorf("unknown %s %q", u.field, value)
+	}
+
+	structType := variantType
+	if structType.Kind() == reflect.Pointer {
+		structType = structType.Elem()
+	}
+
+	ptr := reflect.New(structType)
+	if err := json.Unmarshal(data, ptr.Interface()); err != nil {
+		return zero, fmt.Errorf("failed to decode %s: %w", variantType, err)
+	}
+
+	if variantType.Kind() == reflect.Pointer {
+		return ptr.Interface().(T), nil
+	}
+
+	return ptr.Elem().Interface().(T), nil
+}
@@ -38,6 +38,7 @@ export default async function Method(props: PageProps<"/api/method/[method]">) {
                 <Deprecation
                     type='method'
                     deprecated={data.deprecated}
+                    aliasOf={"aliasOf" in data ? data.aliasOf : undefined}
                 />
 
                 <div className='flex gap-2 mb-4'>
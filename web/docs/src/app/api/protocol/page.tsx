@@ -1,3 +1,4 @@
+import Link from "next/link";
 import { CardBoxWrapper } from "@/components/card-box-wrapper";
 import { CodeWrapper } from "@/components/code-wrapper";
 
@@ -52,6 +53,19 @@ const errorResponseExample = JSON.stringify(
     2
 );
 
+const partialResultExample = JSON.stringify(
+    {
+        jsonrpc: "2.0",
+        id: "550e8400-e29b-41d4-a716-446655440000",
+        result: {
+            results: [{ id: "123e4567-e89b-12d3-a456-426614174000", username: "john_doe" }],
+            errors: [{ index: 1, code: -32602, message: "Invalid email format" }],
+        },
+    },
+    null,
+    2
+);
+
 const eventExample = JSON.stringify(
     {
         event: "data.created",
@@ -65,6 +79,37 @@ const eventExample = JSON.stringify(
     2
 );
 
+const sseExample = `GET /events?event=data.created
+
+event: data.created
+data: {"event":"data.created","data":{"id":"123e4567-e89b-12d3-a456-426614174000"}}
+
+: ping`;
+
+const heartbeatExample = JSON.stringify(
+    {
+        jsonrpc: "2.0",
+        method: "$ping",
+    },
+    null,
+    2
+);
+
+const sessionExample = JSON.stringify(
+    {
+        jsonrpc: "2.0",
+        method: "$session",
+        params: {
+            token: "KZ6Z3UDIEANEGWAB7GM3G3TAQX",
+            expiresAt: "2024-01-01T00:05:00Z",
+            resumed: true,
+            subscriptions: ["data.created"],
+        },
+    },
+    null,
+    2
+);
+
 export default function ProtocolPage() {
     return (
         <main className='flex-1 p-10 overflow-y-auto'>
@@ -100,8 +145,10 @@ export default function ProtocolPage() {
                             <code className='bg-bg-tertiary px-2 py-1 rounded text-sm'>"2.0"</code>
                         </li>
                         <li>
-                            <code className='bg-bg-tertiary px-2 py-1 rounded text-sm'>id</code>: A UUID that uniquely
-                            identifies this request. The response will include the same ID.
+                            <code className='bg-bg-tertiary px-2 py-1 rounded text-sm'>id</code>: A string, number or null
+                            that identifies this request (the bundled client uses UUIDs). The response will include the
+                            same ID, exactly as sent. Requests without an ID are notifications: they are handled, but
+                            get no response, not even an error (over HTTP the reply is an empty 204 No Content).
                         </li>
                         <li>
                             <code className='bg-bg-tertiary px-2 py-1 rounded text-sm'>method</code>: The name of the
@@ -130,7 +177,7 @@ export default function ProtocolPage() {
                             <code className='bg-bg-tertiary px-2 py-1 rounded text-sm'>"2.0"</code>
                         </li>
                         <li>
-                            <code className='bg-bg-tertiary px-2 py-1 rounded text-sm'>id</code>: The same UUID from the
+                            <code className='bg-bg-tertiary px-2 py-1 rounded text-sm'>id</code>: The same ID from the
                             request
                         </li>
                         <li>
@@ -156,7 +203,7 @@ export default function ProtocolPage() {
                             <code className='bg-bg-tertiary px-2 py-1 rounded text-sm'>"2.0"</code>
                         </li>
                         <li>
-                            <code className='bg-bg-tertiary px-2 py-1 rounded text-sm'>id</code>: The same UUID from the
+                            <code className='bg-bg-tertiary px-2 py-1 rounded text-sm'>id</code>: The same ID from the
                             request
                         </li>
                         <li>
@@ -219,17 +266,72 @@ export default function ProtocolPage() {
                                 </td>
                                 <td className='py-2'>Invalid params - Invalid method parameters</td>
                             </tr>
-                            <tr>
+                            <tr className='border-b border-border-primary'>
                                 <td className='py-2'>
                                     <code className='bg-bg-primary px-2 py-1 rounded text-sm'>-32603</code>
                                 </td>
                                 <td className='py-2'>Internal error - Internal JSON-RPC error</td>
                             </tr>
+                            <tr className='border-b border-border-primary'>
+                                <td className='py-2'>
+                                    <code className='bg-bg-primary px-2 py-1 rounded text-sm'>-32002</code>
+                                </td>
+                                <td className='py-2'>
+                                    Server not initialized - A handshake method is registered and the WebSocket
+                                    connection has not called it yet
+                                </td>
+                            </tr>
+                            <tr>
+                                <td className='py-2'>
+                                    <code className='bg-bg-primary px-2 py-1 rounded text-sm'>-32003</code>
+                                </td>
+                                <td className='py-2'>
+                                    Forbidden - The caller is not allowed to call the method, e.g. its API key has no
+                                    access to the method group
+                                </td>
+                            </tr>
                         </tbody>
                     </table>
                 </div>
             </CardBoxWrapper>
 
+            {/* Partial Success */}
+            <CardBoxWrapper title='Partial Success'>
+                <div className='mb-4'>
+                    <p className='text-text-secondary mb-4'>
+                        Methods operating on a batch of items may partially succeed. They still respond with a{" "}
+                        <code className='bg-bg-tertiary px-2 py-1 rounded text-sm'>result</code>, using the{" "}
+                        <code className='bg-bg-tertiary px-2 py-1 rounded text-sm'>PartialResult</code> shape, and only
+                        fail with an <code className='bg-bg-tertiary px-2 py-1 rounded text-sm'>error</code> when the
+                        batch could not be processed at all:
+                    </p>
+                    <ul className='list-disc pl-6 space-y-2 text-text-secondary mb-6'>
+                        <li>
+                            <code className='bg-bg-tertiary px-2 py-1 rounded text-sm'>results</code>: The results of
+                            the succeeded items, in params order
+                        </li>
+                        <li>
+                            <code className='bg-bg-tertiary px-2 py-1 rounded text-sm'>errors</code>: The failed items,
+                            each with its <code className='bg-bg-tertiary px-2 py-1 rounded text-sm'>index</code> in the
+                            params and an error <code className='bg-bg-tertiary px-2 py-1 rounded text-sm'>code</code>{" "}
+                            and <code className='bg-bg-tertiary px-2 py-1 rounded text-sm'>message</code>, with the same
+                            meaning as the error response
+                        </li>
+                    </ul>
+                    <p className='text-text-secondary mb-4'>
+                        Both arrays are always present. A call succeeded for every item when{" "}
+                        <code className='bg-bg-tertiary px-2 py-1 rounded text-sm'>errors</code> is empty. Over HTTP,
+                        methods may answer a partial success with status 207 Multi-Status instead of 200 OK, the body
+                        is the same.
+                    </p>
+                </div>
+                <CodeWrapper
+                    code={partialResultExample}
+                    label={{ text: "Partial Success Example" }}
+                    lang='json'
+                />
+            </CardBoxWrapper>
+
             {/* Event Format (WebSocket only) */}
             <CardBoxWrapper title='Event Format (WebSocket Only)'>
                 <div className='mb-4'>
@@ -250,6 +352,12 @@ export default function ProtocolPage() {
                         Note: Events do not have an <code className='bg-bg-tertiary px-2 py-1 rounded text-sm'>id</code>{" "}
                         field because they are server-initiated messages, not responses to client requests.
                     </p>
+                    <p className='text-text-secondary mb-4'>
+                        If the server has event batching enabled, clients connecting with the{" "}
+                        <code className='bg-bg-tertiary px-2 py-1 rounded text-sm'>batchEvents=true</code> query
+                        parameter receive their events in batches: a JSON array of events, in the order they were
+                        published.
+                    </p>
                 </div>
                 <CodeWrapper
                     code={eventExample}
@@ -257,6 +365,135 @@ export default function ProtocolPage() {
                     lang='json'
                 />
             </CardBoxWrapper>
+
+            {/* Server-Sent Events */}
+            <CardBoxWrapper title='Server-Sent Events'>
+                <div className='mb-4'>
+                    <p className='text-text-secondary mb-4'>
+                        Clients that can not use WebSockets can receive events as Server-Sent Events from the{" "}
+                        <code className='bg-bg-tertiary px-2 py-1 rounded text-sm'>/events</code> endpoint, e.g. with
+                        the browser <code className='bg-bg-tertiary px-2 py-1 rounded text-sm'>EventSource</code>:
+                    </p>
+                    <ul className='list-disc pl-6 space-y-2 text-text-secondary mb-6'>
+                        <li>
+                            Subscribe with one <code className='bg-bg-tertiary px-2 py-1 rounded text-sm'>event</code>{" "}
+                            query parameter per event. Unknown events are rejected with 400 Bad Request.
+                        </li>
+                        <li>
+                            Each event is sent with its name as the SSE event type, and the event message shown above
+                            as data. The names and data types are listed in the{" "}
+                            <Link
+                                href='/api/events'
+                                className='text-accent-blue hover:underline'>
+                                events
+                            </Link>{" "}
+                            documentation.
+                        </li>
+                        <li>
+                            Comment lines (starting with{" "}
+                            <code className='bg-bg-tertiary px-2 py-1 rounded text-sm'>:</code>) keep the connection
+                            alive and should be ignored. Methods can not be called over SSE.
+                        </li>
+                    </ul>
+                </div>
+                <CodeWrapper
+                    code={sseExample}
+                    label={{ text: "SSE Example" }}
+                    lang='http'
+                />
+            </CardBoxWrapper>
+
+            {/* Heartbeat (WebSocket only) */}
+            <CardBoxWrapper title='Heartbeat (WebSocket Only)'>
+                <div className='mb-4'>
+                    <p className='text-text-secondary mb-4'>
+                        Method names starting with <code className='bg-bg-tertiary px-2 py-1 rounded text-sm'>$</code>{" "}
+                        are reserved for the heartbeat and resumable sessions, which the server handles itself:
+                    </p>
+                    <ul className='list-disc pl-6 space-y-2 text-text-secondary mb-6'>
+                        <li>
+                            <code className='bg-bg-tertiary px-2 py-1 rounded text-sm'>$ping</code>: When heartbeats are
+                            enabled, the server periodically sends a{" "}
+                            <code className='bg-bg-tertiary px-2 py-1 rounded text-sm'>$ping</code> notification (without
+                            an <code className='bg-bg-tertiary px-2 py-1 rounded text-sm'>id</code>). Clients must answer
+                            with a <code className='bg-bg-tertiary px-2 py-1 rounded text-sm'>$pong</code> notification,
+                            or the connection is closed. Clients may also call{" "}
+                            <code className='bg-bg-tertiary px-2 py-1 rounded text-sm'>$ping</code> with an id, the
+                            result is <code className='bg-bg-tertiary px-2 py-1 rounded text-sm'>"$pong"</code>.
+                        </li>
+                        <li>
+                            <code className='bg-bg-tertiary px-2 py-1 rounded text-sm'>$pong</code>: The answer to a{" "}
+                            <code className='bg-bg-tertiary px-2 py-1 rounded text-sm'>$ping</code> of the server, it
+                            gets no response.
+                        </li>
+                    </ul>
+                    <p className='text-text-secondary mb-4'>
+                        The heartbeat is independent of WebSocket ping frames, so it also detects dead connections
+                        behind proxies that answer ping frames themselves. The bundled client answers it automatically.
+                    </p>
+                </div>
+                <CodeWrapper
+                    code={heartbeatExample}
+                    label={{ text: "Heartbeat Example" }}
+                    lang='json'
+                />
+            </CardBoxWrapper>
+
+            {/* Resumable Sessions (WebSocket only) */}
+            <CardBoxWrapper title='Resumable Sessions (WebSocket Only)'>
+                <div className='mb-4'>
+                    <p className='text-text-secondary mb-4'>
+                        When resumable sessions are enabled, the server sends a{" "}
+                        <code className='bg-bg-tertiary px-2 py-1 rounded text-sm'>$session</code> notification on connect, carrying a session token.
+                        Clients reconnecting with the token in the{" "}
+                        <code className='bg-bg-tertiary px-2 py-1 rounded text-sm'>session</code> query parameter get the subscriptions of
+                        their previous connection restored, listed in the{" "}
+                        <code className='bg-bg-tertiary px-2 py-1 rounded text-sm'>subscriptions</code> of the new notification with{" "}
+                        <code className='bg-bg-tertiary px-2 py-1 rounded text-sm'>resumed</code> set to true.
+                    </p>
+                    <ul className='list-disc pl-6 space-y-2 text-text-secondary mb-6'>
+                        <li>Every token can be used once, a new one is issued on every connection.</li>
+                        <li>
+                            Reconnecting before the server noticed the previous connection dropped works too, its
+                            subscriptions are taken over by the new connection and the previous connection is closed.
+                        </li>
+                        <li>
+                            Tokens expire a few minutes after the client disconnects. Unknown or expired tokens start a
+                            new session, so clients must then resubscribe themselves.
+                        </li>
+                        <li>
+                            A token only resumes a session for the same caller, it does not authenticate the client on
+                            its own. Tokens are sent in the URL, so only connect over TLS.
+                        </li>
+                        <li>
+                            Only subscriptions are restored, events published while the client was disconnected are
+                            not replayed.
+                        </li>
+                    </ul>
+                </div>
+                <CodeWrapper
+                    code={sessionExample}
+                    label={{ text: "Session Example" }}
+                    lang='json'
+                />
+            </CardBoxWrapper>
+
+            {/* Server Requests (WebSocket only) */}
+            <CardBoxWrapper title='Server Requests (WebSocket Only)'>
+                <div className='mb-4'>
+                    <p className='text-text-secondary mb-4'>
+                        The server may also call methods on the client, sending a regular request with a server
+                        generated <code className='bg-bg-tertiary px-2 py-1 rounded text-sm'>id</code>. Clients answer
+                        with a regular response carrying the same{" "}
+                        <code className='bg-bg-tertiary px-2 py-1 rounded text-sm'>id</code>, either a{" "}
+                        <code className='bg-bg-tertiary px-2 py-1 rounded text-sm'>result</code> or an{" "}
+                        <code className='bg-bg-tertiary px-2 py-1 rounded text-sm'>error</code>. Clients that do not
+                        handle the method should answer with a{" "}
+                        <code className='bg-bg-tertiary px-2 py-1 rounded text-sm'>-32601</code> error, so the server
+                        does not wait for a response until it times out.
+                    </p>
+                </div>
+            </CardBoxWrapper>
         </main>
     );
 }
@@ -1,13 +1,29 @@
+import Link from "next/link";
 import type { ItemType } from "@/data/api";
 
 type Props = {
     type: ItemType;
     deprecated: boolean;
+    aliasOf?: string;
 };
 
-export const Deprecation = ({ type, deprecated }: Props) => {
+export const Deprecation = ({ type, deprecated, aliasOf }: Props) => {
     if (!deprecated) return null;
 
+    if (aliasOf) {
+        return (
+            <div className='bg-warning-bg border border-warning-border px-4 py-3 rounded-lg mb-4 text-warning-text'>
+                ⚠️ This {type} is a deprecated alias of{" "}
+                <Link
+                    href={`/api/method/${aliasOf}`}
+                    className='font-mono underline decoration-dotted'>
+                    {aliasOf}
+                </Link>
+                , use it instead.
+            </div>
+        );
+    }
+
     return (
         <div className='bg-warning-bg border border-warning-border px-4 py-3 rounded-lg mb-4 text-warning-text'>
             ⚠️ This {type} is deprecated and may be removed in a future version.
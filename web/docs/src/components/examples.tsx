@@ -1,7 +1,9 @@
+import { TbFileCode, TbJson } from "react-icons/tb";
 import type { ExampleData } from "@/data/api";
 import { CardBoxWrapper } from "./card-box-wrapper";
 import { CodeWrapper } from "./code-wrapper";
 import { CollapsibleCard } from "./collapsible-group";
+import { TabbedCardWrapper } from "./tabbed-card-wrapper-client";
 
 type Props = {
     isMethod?: boolean;
@@ -36,22 +38,81 @@ type ContentProps = {
 const Content = ({ example, isMethod }: ContentProps) => {
     return (
         <>
-            {example.params && (
+            {"resultYaml" in example && example.resultYaml ? (
+                <TabbedCardWrapper
+                    tabs={[
+                        {
+                            title: "JSON",
+                            icon: <TbJson className='w-8 h-8 text-lang-json' />,
+                            code: (
+                                <Payloads
+                                    params={example.params}
+                                    result={example.result}
+                                    isMethod={isMethod}
+                                    lang='json'
+                                />
+                            ),
+                        },
+                        {
+                            title: "YAML",
+                            icon: <TbFileCode className='w-8 h-8 text-lang-yaml' />,
+                            code: (
+                                <Payloads
+                                    params={"paramsYaml" in example && example.paramsYaml ? example.paramsYaml : ""}
+                                    result={example.resultYaml}
+                                    isMethod={isMethod}
+                                    lang='yaml'
+                                />
+                            ),
+                        },
+                    ]}
+                />
+            ) : (
+                <Payloads
+                    params={example.params}
+                    result={example.result}
+                    isMethod={isMethod}
+                    lang='json'
+                />
+            )}
+            {"curl" in example && example.curl && (
+                <CodeWrapper
+                    label={{ text: "curl" }}
+                    code={example.curl}
+                    lang='bash'
+                />
+            )}
+        </>
+    );
+};
+
+type PayloadsProps = {
+    params: string;
+    result: string;
+    isMethod?: boolean;
+    lang: "json" | "yaml";
+};
+
+// Params and result render the same in every format, "null" meaning there are none
+const Payloads = ({ params, result, isMethod, lang }: PayloadsProps) => {
+    return (
+        <>
+            {params && (
                 <CodeWrapper
                     label={{ text: "Request Params" }}
-                    code={example.params !== "null" ? example.params : null}
+                    code={params !== "null" ? params : null}
                     noCodeMessage='No parameters'
-                    lang='json'
+                    lang={lang}
                 />
             )}
-            {example.result && (
+            {result && (
                 <CodeWrapper
                     label={{
                         text: isMethod ? "Response Result" : "Event Data",
                     }}
-                    code={example.result !== "null" ? example.result : null}
+                    code={result !== "null" ? result : null}
                     noCodeMessage={isMethod ? "No result" : "No data"}
-                    lang='json'
+                    lang={lang}
                 />
             )}
         </>
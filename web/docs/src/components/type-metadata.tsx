@@ -96,6 +96,16 @@ function FieldItem({ field }: { field: FieldMetadata }) {
                             optional
                         </span>
                     )}
+                    {field.nullable && (
+                        <span className='text-xs px-2 py-0.5 rounded bg-yellow-500/20 text-yellow-400 border border-yellow-500/30'>
+                            nullable
+                        </span>
+                    )}
+                    {field.sensitive && (
+                        <span className='text-xs px-2 py-0.5 rounded bg-red-500/20 text-red-400 border border-red-500/30'>
+                            sensitive
+                        </span>
+                    )}
                 </div>
                 {isTypeLink(field.type) ? (
                     <Link
@@ -110,6 +120,12 @@ function FieldItem({ field }: { field: FieldMetadata }) {
 
             {field.description && <p className='text-sm text-text-tertiary mb-2'>{field.description}</p>}
 
+            {"example" in field && field.example !== undefined && (
+                <p className='text-xs text-text-tertiary mb-2'>
+                    Example: <code className='text-type-primitive font-mono'>{JSON.stringify(field.example)}</code>
+                </p>
+            )}
+
             {"enumValues" in field && field.enumValues && field.enumValues.length > 0 && (
                 <div className='mt-3'>
                     <p className='text-xs text-text-tertiary mb-2'>Possible values:</p>
@@ -1,7 +1,9 @@
 package web
 
 import (
+	"crypto/sha256"
 	"embed"
+	"encoding/hex"
 	"io/fs"
 	"log"
 	"log/slog"
@@ -19,6 +21,7 @@ type WebApp struct {
 	l       *slog.Logger
 	fs      fs.FS
 	urlBase string
+	etags   map[string]string // File path -> ETag (content hash), computed once as the files are static
 }
 
 func NewWebApp(name string, app fs.FS, subDir string, urlBase string) WebApp {
@@ -30,12 +33,44 @@ func NewWebApp(name string, app fs.FS, subDir string, urlBase string) WebApp {
 	// Ensure urlBase ends with /
 	urlBase = strings.TrimSuffix(urlBase, "/") + "/"
 
+	l := slog.Default().With(slog.String("component", name))
+
 	return WebApp{
 		name:    name,
 		fs:      subFS,
 		urlBase: urlBase,
-		l:       slog.Default().With(slog.String("component", name)),
+		etags:   computeETags(l, subFS),
+		l:       l,
+	}
+}
+
+// computeETags hashes every file of the app, so responses can be revalidated with If-None-Match.
+// Files that cannot be read are skipped and served without an ETag.
+func computeETags(l *slog.Logger, app fs.FS) map[string]string {
+	etags := make(map[string]string)
+
+	err := fs.WalkDir(app, ".", func(path string, d fs.DirEntry, err error) error {
+		if err != nil || d.IsDir() {
+			return err
+		}
+
+		data, err := fs.ReadFile(app, path)
+		if err != nil {
+			l.Warn("Failed to hash file", slog.String("path", path), slog.String("error", err.Error()))
+
+			return nil
+		}
+
+		sum := sha256.Sum256(data)
+		etags[path] = `"` + hex.EncodeToString(sum[:16]) + `"`
+
+		return nil
+	})
+	if err != nil {
+		l.Warn("Failed to compute ETags", slog.String("error", err.Error()))
 	}
+
+	return etags
 }
 
 func (wa WebApp) ServeHTTP(w http.ResponseWriter, r *http.Request) {
@@ -53,6 +88,13 @@ func (wa WebApp) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 				continue
 			}
 
+			// ServeFileFS answers If-None-Match with 304 when the ETag header is set.
+			// Embedded files have no modification time, so the ETag is the only validator.
+			if etag, ok := wa.etags[altPath]; ok {
+				w.Header().Set("ETag", etag)
+				w.Header().Set("Cache-Control", "no-cache")
+			}
+
 			http.ServeFileFS(w, r, wa.fs, altPath)
 
 			return
@@ -1,8 +1,19 @@
 import type * as T from "./generated";
+import { DirectEventKinds } from "./generated";
 export type EventKind = keyof APIEvents;
+/**
+ * Events the client can subscribe to. The others are sent by the server to a single client
+ * (like the "subscribed" confirmation), they can only be listened to.
+ */
+export type SubscribableEventKind = Exclude<EventKind, T.DirectEventKind>;
+
+export function isSubscribable(event: EventKind): event is SubscribableEventKind {
+    return !(DirectEventKinds as readonly string[]).includes(event);
+}
 /**
  * Mapping of event names to their data types
  */
 export type APIEvents = {
     "data.created": T.DataCreatedEvent;
+    subscribed: T.SubscribedEvent;
 };
@@ -12,15 +12,20 @@ export type DataCreatedEvent = {
     id: string;
 };
 
+// From rpctypes/coretypes.go
+export type DirectEventKind = "subscribed";
+
+export const DirectEventKinds: DirectEventKind[] = ["subscribed"];
+
 // From rpctypes/coretypes.go
 export type EventKind = "data.created" | "data.updated";
 
 export const EventKinds: EventKind[] = ["data.created", "data.updated"];
 
 // From rpctypes/coretypes.go
-export type MethodKind = "ping" | "subscribe" | "unsubscribe" | "user.create" | "user.delete" | "user.get" | "user.list" | "user.update";
+export type MethodKind = "ping" | "rpc.info" | "subscribe" | "unsubscribe" | "user.create" | "user.delete" | "user.get" | "user.list" | "user.update";
 
-export const MethodKinds: MethodKind[] = ["ping", "subscribe", "unsubscribe", "user.create", "user.delete", "user.get", "user.list", "user.update"];
+export const MethodKinds: MethodKind[] = ["ping", "rpc.info", "subscribe", "unsubscribe", "user.create", "user.delete", "user.get", "user.list", "user.update"];
 
 // From rpctypes/types.go
 /**
@@ -29,6 +34,7 @@ export const MethodKinds: MethodKind[] = ["ping", "subscribe", "unsubscribe", "u
 export type PingResult = {
     /**
      * A message describing the result
+     * @example pong
      */
     message: string;
     /**
@@ -42,6 +48,30 @@ export type PingStatus = "error" | "success";
 
 export const PingStatuses: PingStatus[] = ["error", "success"];
 
+// From rpctypes/types.go
+/**
+ * RPCInfoResult - Result for the [MethodKindRPCInfo] method.
+ */
+export type RPCInfoResult = {
+    /**
+     * The title of the API
+     */
+    title: string;
+    /**
+     * The version of the server, including the build commit
+     */
+    version: string;
+    /**
+     * The git commit the server was built from
+     * @example abc1234
+     */
+    commit: string;
+    /**
+     * A hash of the names and shapes of all methods and events, which changes whenever the API does
+     */
+    signatureHash: string;
+};
+
 // From rpctypes/types.go
 /**
  * SubscribeParams - Parameters for the [MethodKindSubscribe] method.
@@ -51,6 +81,10 @@ export type SubscribeParams = {
      * The event topic to subscribe to
      */
     event: EventKind;
+    /**
+     * Send a [DirectEventKindSubscribed] event once the subscription is active
+     */
+    confirm?: boolean;
 };
 
 // From rpctypes/types.go
@@ -62,6 +96,26 @@ export type SubscribeResult = {
      * Whether the subscribe was successful
      */
     success: boolean;
+    /**
+     * The event topics the client is subscribed to after the subscribe
+     */
+    subscriptions: EventKind[];
+};
+
+// From rpctypes/types.go
+/**
+ * SubscribedEvent - Result for the [DirectEventKindSubscribed] event.
+ */
+export type SubscribedEvent = {
+    /**
+     * The event topic the subscription is active for
+     */
+    event: EventKind;
+    /**
+     * The latest data published for the event, if the event keeps a snapshot and was published before
+     */
+    // empty interface{} type, falling back to unknown
+    snapshot?: unknown;
 };
 
 // From rpctypes/types.go
@@ -84,5 +138,9 @@ export type UnsubscribeResult = {
      * Whether the unsubscribe was successful
      */
     success: boolean;
+    /**
+     * The event topics the client is subscribed to after the unsubscribe
+     */
+    subscriptions: EventKind[];
 };
 
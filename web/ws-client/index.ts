@@ -1,7 +1,18 @@
 import { v4 as uuidv4 } from "uuid";
-import type { APIEvents, EventKind } from "./events";
+import type { APIEvents, EventKind, SubscribableEventKind } from "./events";
+import { isSubscribable } from "./events";
 import type { APIMethods, MethodKind } from "./methods";
-import type { EventHandler, EventMessage, IncomingMessage, RequestMessage, ResponseMessage } from "./types";
+import type {
+    EventHandler,
+    EventMessage,
+    HeartbeatMessage,
+    IncomingMessage,
+    RequestMessage,
+    ResponseMessage,
+    ServerRequestHandler,
+    ServerRequestMessage,
+    SessionMessage,
+} from "./types";
 
 // JSON-RPC error codes (https://www.jsonrpc.org/specification#error_object)
 const RPC_ERROR_CODE = {
@@ -10,6 +21,10 @@ const RPC_ERROR_CODE = {
     METHOD_NOT_FOUND: -32601,
     INVALID_PARAMS: -32602,
     INTERNAL_ERROR: -32603,
+    // Server defined: the handshake method must be called first
+    SERVER_NOT_INITIALIZED: -32002,
+    // Server defined: the caller is not allowed to call the method
+    FORBIDDEN: -32003,
 } as const;
 
 // Client options
@@ -20,6 +35,8 @@ export interface WebSocketClientOptions {
     maxReconnectAttempts?: number;
     requestTimeout?: number;
     connectionTimeout?: number;
+    // Ask the server to send events in batches (JSON arrays), if it has event batching enabled
+    batchEvents?: boolean;
     jsonReplacer?: (key: string, value: unknown) => unknown;
     jsonReviver?: (key: string, value: unknown) => unknown;
     onMessageParseError?: (error: Error, rawData: string) => void;
@@ -50,6 +67,7 @@ export class WebSocketClient {
     private maxReconnectAttempts: number;
     private requestTimeout: number;
     private connectionTimeout: number;
+    private batchEvents: boolean;
     private reconnectAttempts = 0;
     private reconnectTimer: ReturnType<typeof setTimeout> | null = null;
     private connectionTimer: ReturnType<typeof setTimeout> | null = null;
@@ -65,8 +83,12 @@ export class WebSocketClient {
 
     // Event handlers - multiple handlers per event
     private eventHandlers = new Map<EventKind, Set<EventHandler<APIEvents[EventKind]>>>();
+    // Handlers of the methods the server can call on the client
+    private serverRequestHandlers = new Map<string, ServerRequestHandler>();
     // Track events we've subscribed to on the server (separate from local handlers)
-    private serverSubscriptions = new Set<EventKind>();
+    private serverSubscriptions = new Set<SubscribableEventKind>();
+    // Token of the resumable session issued by the server, presented when reconnecting
+    private sessionToken: string | null = null;
     private connectionHandlers: {
         onConnect?: () => void;
         onDisconnect?: () => void;
@@ -81,6 +103,7 @@ export class WebSocketClient {
         this.maxReconnectAttempts = options.maxReconnectAttempts ?? 5;
         this.requestTimeout = options.requestTimeout ?? 20000;
         this.connectionTimeout = options.connectionTimeout ?? 3000;
+        this.batchEvents = options.batchEvents ?? false;
         this.jsonReplacer = options.jsonReplacer;
         this.jsonReviver = options.jsonReviver;
         this.onMessageParseError = options.onMessageParseError;
@@ -135,10 +158,11 @@ export class WebSocketClient {
             this.handleMessage(event.data);
         };
 
-        this.ws.onclose = () => {
+        this.ws.onclose = (event) => {
             this.clearConnectionTimeout();
             this.isConnecting = false;
-            this.logger("info", "Connection closed");
+            // The server sends a close code and reason when it closes the connection (e.g. 1001 going away, 1013 try again later)
+            this.logger("info", `Connection closed (${event.code}${event.reason ? `: ${event.reason}` : ""})`);
             this.connectionHandlers.onDisconnect?.();
 
             if (!this.isManualClose) {
@@ -184,6 +208,12 @@ export class WebSocketClient {
             try {
                 const newUrl = new URL(this.url);
                 newUrl.searchParams.set("clientID", this.clientId);
+                if (this.batchEvents) {
+                    newUrl.searchParams.set("batchEvents", "true");
+                }
+                if (this.sessionToken) {
+                    newUrl.searchParams.set("session", this.sessionToken);
+                }
                 this.logger("info", `Connecting to URL: ${newUrl.toString()}`);
                 this.ws = new WebSocket(newUrl.toString());
 
@@ -292,11 +322,67 @@ export class WebSocketClient {
         pending.resolve(message);
     }
 
+    private async handleServerRequest(message: ServerRequestMessage): Promise<void> {
+        const handler = this.serverRequestHandlers.get(message.method);
+        if (!handler) {
+            this.logger("warn", `No handler registered for server request: ${message.method}`);
+            this.send({
+                jsonrpc: "2.0",
+                id: message.id,
+                error: { code: RPC_ERROR_CODE.METHOD_NOT_FOUND, message: `Method "${message.method}" not found` },
+            } satisfies ResponseMessage);
+            return;
+        }
+
+        try {
+            const result = await handler(message.params);
+            this.send({ jsonrpc: "2.0", id: message.id, result: result ?? null } satisfies ResponseMessage);
+        } catch (error) {
+            this.send({
+                jsonrpc: "2.0",
+                id: message.id,
+                error: { code: RPC_ERROR_CODE.INTERNAL_ERROR, message: error instanceof Error ? error.message : String(error) },
+            } satisfies ResponseMessage);
+        }
+    }
+
     // Message handling
     private handleMessage(data: string): void {
         try {
             const message: IncomingMessage = JSON.parse(data, this.jsonReviver);
 
+            // Handle a batch of events, in the order they were published
+            if (Array.isArray(message)) {
+                for (const event of message) {
+                    this.handleEvent(event);
+                }
+                return;
+            }
+
+            // Answer server heartbeats, the server closes connections that stop answering
+            if ("method" in message) {
+                if (message.method === "$ping") {
+                    this.send({ jsonrpc: "2.0", method: "$pong" } satisfies HeartbeatMessage);
+                    return;
+                }
+
+                // Keep the session token to resume the session on reconnect, every token is single use
+                if (message.method === "$session") {
+                    const { params } = message as SessionMessage;
+                    this.sessionToken = params.token;
+                    this.logger("debug", `Session ${params.resumed ? "resumed" : "started"}`);
+                    return;
+                }
+
+                // Answer requests of the server, notifications get no response
+                if ("id" in message && message.id != null) {
+                    this.handleServerRequest(message).catch((error) => {
+                        this.logger("error", `Failed to answer server request ${message.method}: ${error}`);
+                    });
+                }
+                return;
+            }
+
             // Handle response
             if ("id" in message) {
                 this.handleResponse(message);
@@ -394,6 +480,19 @@ export class WebSocketClient {
         return this._call(method, params);
     }
 
+    /**
+     * Handle a method the server calls on the client, replacing any previous handler of the method.
+     * The handler result is sent back as the result, thrown errors are sent as internal errors.
+     * Requests for methods without a handler are answered with a method not found error.
+     * Returns a function that can be called to remove the handler.
+     */
+    handleServerMethod(method: string, handler: ServerRequestHandler): () => void {
+        this.serverRequestHandlers.set(method, handler);
+        return () => {
+            if (this.serverRequestHandlers.get(method) === handler) this.serverRequestHandlers.delete(method);
+        };
+    }
+
     /**
      * Add an event handler.
      * Returns a function that can be called to detach the handler.
@@ -406,7 +505,8 @@ export class WebSocketClient {
             this.eventHandlers.set(event, handlers);
         }
 
-        if (!this.serverSubscriptions.has(event)) await this.subscribe(event);
+        // Events sent directly to the client have no subscription
+        if (isSubscribable(event) && !this.serverSubscriptions.has(event)) await this.subscribe(event);
 
         // Cast needed due to TypeScript variance with Set
         handlers.add(handler as EventHandler<APIEvents[EventKind]>);
@@ -437,23 +537,30 @@ export class WebSocketClient {
         // If no more handlers for this event, remove the entry
         if (handlers.size === 0) {
             this.eventHandlers.delete(event);
-            // Fire-and-forget unsubscribe
-            this.unsubscribe(event).catch((error) => {
-                this.logger("error", `Failed to unsubscribe from event ${String(event)}: ${error}`);
-            });
+            // Fire-and-forget unsubscribe, events sent directly to the client have no subscription
+            if (isSubscribable(event)) {
+                this.unsubscribe(event).catch((error) => {
+                    this.logger("error", `Failed to unsubscribe from event ${String(event)}: ${error}`);
+                });
+            }
         }
     }
 
     /**
      * Subscribe to an event.
      * Will automatically resubscribe if the connection is lost.
+     * Set confirm to receive a "subscribed" event (with the latest snapshot, if the event keeps one)
+     * once the subscription is active.
      */
-    async subscribe(event: EventKind): Promise<ResponseMessage<APIMethods["subscribe"]["res"]>> {
+    async subscribe(
+        event: SubscribableEventKind,
+        options: { confirm?: boolean } = {}
+    ): Promise<ResponseMessage<APIMethods["subscribe"]["res"]>> {
         // Track this subscription
         this.serverSubscriptions.add(event);
 
         // Call subscribe on the server
-        const response = (await this._call("subscribe", { event })) as ResponseMessage<APIMethods["subscribe"]["res"]>;
+        const response = (await this._call("subscribe", { event, confirm: options.confirm })) as ResponseMessage<APIMethods["subscribe"]["res"]>;
 
         if (response.error) {
             this.logger("error", `Failed to subscribe to ${String(event)}: ${response.error.message}`);
@@ -468,7 +575,7 @@ export class WebSocketClient {
      * Unsubscribe from an event.
      * Will also remove any handlers for the event.
      */
-    async unsubscribe(event: EventKind): Promise<ResponseMessage<APIMethods["unsubscribe"]["res"]>> {
+    async unsubscribe(event: SubscribableEventKind): Promise<ResponseMessage<APIMethods["unsubscribe"]["res"]>> {
         // Remove from tracked subscriptions
         this.serverSubscriptions.delete(event);
         this.eventHandlers.delete(event);
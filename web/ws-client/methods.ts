@@ -7,6 +7,7 @@ export type MethodKind = keyof APIMethods;
  * */
 export type APIMethods = {
     ping: { req: never; res: T.PingResult };
+    "rpc.info": { req: never; res: T.RPCInfoResult };
     subscribe: { req: T.SubscribeParams; res: T.SubscribeResult };
     unsubscribe: { req: T.UnsubscribeParams; res: T.UnsubscribeResult };
 };
@@ -3,8 +3,14 @@ import type { APIMethods, MethodKind } from "./methods";
 
 type UUID = string;
 
-// Incoming message is either a response or an event
-export type IncomingMessage = ResponseMessage | EventMessage;
+// Incoming message is either a response, an event, a batch of events, a server heartbeat, a session or a server request
+export type IncomingMessage =
+    | ResponseMessage
+    | EventMessage
+    | EventMessage[]
+    | HeartbeatMessage
+    | SessionMessage
+    | ServerRequestMessage;
 
 // Event handler function type
 export type EventHandler<T> = (data: T) => void;
@@ -28,6 +34,36 @@ export type ResponseMessage<Result = unknown> = {
           error: { code: number; message: string; data?: unknown };
       }
 );
+// Heartbeat notification sent by the server, answered with a "$pong" notification
+export type HeartbeatMessage = {
+    jsonrpc: "2.0";
+    method: "$ping" | "$pong";
+};
+
+// Session notification sent by the server on connect, when resumable sessions are enabled.
+// Presenting the token in the "session" query parameter when reconnecting restores the subscriptions.
+export type SessionMessage = {
+    jsonrpc: "2.0";
+    method: "$session";
+    params: {
+        token: string;
+        expiresAt: string;
+        resumed: boolean;
+        subscriptions: string[];
+    };
+};
+
+// Request sent by the server to call a method on the client, answered with a response message
+export type ServerRequestMessage = {
+    jsonrpc: "2.0";
+    id: string;
+    method: string;
+    params?: unknown;
+};
+
+// Handler of a server request, returning (or resolving to) the result. Thrown errors are sent as internal errors.
+export type ServerRequestHandler = (params: unknown) => unknown | Promise<unknown>;
+
 export type EventMessage = {
     [K in EventKind]: {
         event: K;